@@ -0,0 +1,108 @@
+package analytics
+
+import (
+	"Bingo/bingo"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// FieldStats describes how a single field of a word list performed over all stored games.
+type FieldStats struct {
+	Field string `json:"field"`
+	Games int    `json:"games"`
+	Hits  int    `json:"hits"`
+	// HitRate is the share of games in which the field was completed.
+	HitRate float64 `json:"hitRate"`
+	// AverageTime is the average time in seconds from the start of a game
+	// until the field was completed. It is 0 if the field was never completed.
+	AverageTime float64 `json:"averageTimeSeconds"`
+	Rerolls     int     `json:"rerolls"`
+	// RerollRate is the average number of rerolls of the field per game.
+	RerollRate float64 `json:"rerollRate"`
+}
+
+// ForKind aggregates the field statistics of all stored games of the given kind,
+// ordered by hit rate with the most frequent fields first.
+func ForKind(path, kind string) ([]*FieldStats, error) {
+	bins, err := bingo.LoadAll(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]*FieldStats)
+	timed := make(map[string]int)
+	totalTime := make(map[string]time.Duration)
+
+	for _, bin := range bins {
+		if bin.Kind != kind {
+			continue
+		}
+
+		for _, word := range bin.Words {
+			field, exists := fields[word]
+			if !exists {
+				field = &FieldStats{Field: word}
+				fields[word] = field
+			}
+
+			field.Games++
+			field.Rerolls += bin.Rerolled[word]
+			if !bin.Completed[word] {
+				continue
+			}
+			field.Hits++
+
+			completedAt, exists := bin.CompletedAt[word]
+			if exists && !bin.Created.IsZero() {
+				timed[word]++
+				totalTime[word] += completedAt.Sub(bin.Created)
+			}
+		}
+	}
+
+	sorted := make([]*FieldStats, 0, len(fields))
+	for word, field := range fields {
+		field.HitRate = float64(field.Hits) / float64(field.Games)
+		field.RerollRate = float64(field.Rerolls) / float64(field.Games)
+		if timed[word] > 0 {
+			field.AverageTime = totalTime[word].Seconds() / float64(timed[word])
+		}
+		sorted = append(sorted, field)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].HitRate != sorted[j].HitRate {
+			return sorted[i].HitRate > sorted[j].HitRate
+		}
+		return sorted[i].Field < sorted[j].Field
+	})
+
+	return sorted, nil
+}
+
+// Weights turns field statistics into selection weights for new boards.
+// Fields that rarely happen or are rerolled often get a lower weight so boards
+// are not filled with fields that never complete. The hit rate is smoothed so
+// new or rarely played fields still show up.
+func Weights(fields []*FieldStats) map[string]float64 {
+	weights := make(map[string]float64)
+	for _, field := range fields {
+		hitRate := (float64(field.Hits) + 1) / (float64(field.Games) + 2)
+		weights[field.Field] = hitRate / (1 + field.RerollRate)
+	}
+	return weights
+}
+
+// WriteReport prints the field statistics as a plain text table.
+func WriteReport(w io.Writer, kind string, fields []*FieldStats) {
+	fmt.Fprintf(w, "Field statistics for %s\n", kind)
+	fmt.Fprintf(w, "%-30s %6s %6s %8s %10s %8s\n", "Field", "Games", "Hits", "HitRate", "AvgTime", "Rerolls")
+	for _, field := range fields {
+		avgTime := "-"
+		if field.AverageTime > 0 {
+			avgTime = (time.Duration(field.AverageTime) * time.Second).String()
+		}
+		fmt.Fprintf(w, "%-30s %6d %6d %7.0f%% %10s %8.2f\n", field.Field, field.Games, field.Hits, field.HitRate*100, avgTime, field.RerollRate)
+	}
+}
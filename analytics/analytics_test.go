@@ -0,0 +1,65 @@
+package analytics
+
+import (
+	"Bingo/bingo"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func storeGame(t *testing.T, path, id, kind string, completed map[string]time.Duration, rerolled map[string]int) {
+	created := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	bin := &bingo.Bingo{
+		Kind:        kind,
+		Id:          id,
+		Created:     created,
+		Words:       []string{"often", "sometimes", "never"},
+		Completed:   map[string]bool{"often": false, "sometimes": false, "never": false},
+		CompletedAt: make(map[string]time.Time),
+		Rerolled:    rerolled,
+	}
+	for word, after := range completed {
+		bin.Completed[word] = true
+		bin.CompletedAt[word] = created.Add(after)
+	}
+	if err := bin.Store(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestForKind(t *testing.T) {
+	path := t.TempDir() + "/"
+	storeGame(t, path, "game1", "test", map[string]time.Duration{"often": time.Minute, "sometimes": time.Minute}, map[string]int{"never": 2})
+	storeGame(t, path, "game2", "test", map[string]time.Duration{"often": 3 * time.Minute}, map[string]int{"never": 1, "sometimes": 1})
+	storeGame(t, path, "game3", "other", map[string]time.Duration{"never": time.Minute}, nil)
+
+	fields, err := ForKind(path, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []FieldStats{
+		{Field: "often", Games: 2, Hits: 2, HitRate: 1, AverageTime: 120},
+		{Field: "sometimes", Games: 2, Hits: 1, HitRate: 0.5, AverageTime: 60, Rerolls: 1, RerollRate: 0.5},
+		{Field: "never", Games: 2, Rerolls: 3, RerollRate: 1.5},
+	}
+	if len(fields) != len(expected) {
+		t.Fatalf("expected %d fields, got %d", len(expected), len(fields))
+	}
+	for i, field := range fields {
+		if *field != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], *field)
+		}
+	}
+
+	weights := Weights(fields)
+	if !(weights["often"] > weights["sometimes"] && weights["sometimes"] > weights["never"] && weights["never"] > 0) {
+		t.Errorf("expected rarely completed and rerolled fields to weigh less but more than 0, got %v", weights)
+	}
+
+	report := &bytes.Buffer{}
+	WriteReport(report, "test", fields)
+	if !strings.Contains(report.String(), "Field statistics for test") || !strings.Contains(report.String(), "2m0s") {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+}
@@ -0,0 +1,98 @@
+package bingo
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxAutomationResults is the number of idempotency keys a bingo remembers.
+const maxAutomationResults = 500
+
+// AutomationResult is the outcome of an automation request. It is returned
+// again for retries with the same idempotency key instead of applying them.
+type AutomationResult struct {
+	Event     string `json:"event"`
+	Field     string `json:"field"`
+	Completed bool   `json:"completed"`
+	// Changed is false if the field already had the value.
+	Changed bool      `json:"changed"`
+	Time    time.Time `json:"time"`
+}
+
+// newSecret returns a random hex string for keys that must not be guessable.
+func newSecret() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// EnsureApiKey creates the automation key of bingos stored before there were keys.
+// It reports whether the key was created and the bingo needs to be stored.
+func (b *Bingo) EnsureApiKey() (bool, error) {
+	if b.ApiKey != "" {
+		return false, nil
+	}
+	key, err := newSecret()
+	if err != nil {
+		return false, err
+	}
+	b.ApiKey = key
+	return true, nil
+}
+
+// ResolveEvent returns the field an automation event marks. The event is looked
+// up in the aliases of the kind first, then compared to the fields, ignoring the case.
+func (b *Bingo) ResolveEvent(aliases map[string]map[string]string, event string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(event))
+	if name == "" {
+		return "", errors.New("no event")
+	}
+	if field, exists := aliases[b.Kind][name]; exists {
+		if _, inBingo := b.Completed[field]; !inBingo {
+			return "", errors.New("the field " + field + " of event " + event + " is not in this bingo")
+		}
+		return field, nil
+	}
+	for field := range b.Completed {
+		if strings.ToLower(field) == name {
+			return field, nil
+		}
+	}
+	return "", errors.New("unknown event " + event)
+}
+
+// AutomationResultFor returns the stored result of the idempotency key.
+func (b *Bingo) AutomationResultFor(key string) (AutomationResult, bool) {
+	result, exists := b.AutomationResults[key]
+	return result, exists
+}
+
+// RememberAutomationResult stores the result of the idempotency key and
+// forgets the oldest keys beyond maxAutomationResults.
+func (b *Bingo) RememberAutomationResult(key string, result AutomationResult) {
+	if b.AutomationResults == nil {
+		b.AutomationResults = make(map[string]AutomationResult)
+	}
+	b.AutomationResults[key] = result
+	if len(b.AutomationResults) <= maxAutomationResults {
+		return
+	}
+
+	keys := make([]string, 0, len(b.AutomationResults))
+	for key := range b.AutomationResults {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return b.AutomationResults[keys[i]].Time.Before(b.AutomationResults[keys[j]].Time)
+	})
+	for _, key := range keys[:len(keys)-maxAutomationResults] {
+		delete(b.AutomationResults, key)
+	}
+}
@@ -4,9 +4,15 @@ import (
 	"Bingo/config"
 	"Bingo/random"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
+	"math"
 	"math/rand"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 type Bingo struct {
@@ -18,16 +24,71 @@ type Bingo struct {
 	Boards    map[string]*BingoBoard `json:"boards"`
 	Id        string                 `json:"id"`
 	OwnerId   string                 `json:"ownerID"`
-	GuildId   string                 `json:"guildID`
+	GuildId   string                 `json:"guildID"`
+	ChannelId string                 `json:"channelID"`
 	Password  string                 `json:"password"`
+	// SpectatorKey protects the spectator page. It is empty if the page is public.
+	SpectatorKey string    `json:"spectatorKey"`
+	Created      time.Time `json:"created"`
+	Winners      []Winner  `json:"winners"`
+	// CompletedAt holds the time each currently completed field was marked.
+	CompletedAt map[string]time.Time `json:"completedAt"`
+	// Rerolled counts how often each field was rerolled away from a board.
+	Rerolled map[string]int `json:"rerolled"`
+	// Weights biases the field selection of new boards. Fields without a
+	// weight are picked with weight 1. A nil map means uniform selection.
+	Weights map[string]float64 `json:"weights,omitempty"`
+	// Difficulty holds the difficulty of every field that has one in the word list.
+	Difficulty map[string]int `json:"difficulty,omitempty"`
+	// TargetDifficulty is the average field difficulty new boards are balanced
+	// to. 0 disables balancing.
+	TargetDifficulty int `json:"targetDifficulty,omitempty"`
+	// DifficultyTolerance is how far the difficulty sum of a balanced board may be off its target.
+	DifficultyTolerance int `json:"difficultyTolerance,omitempty"`
+	// WinPattern is the name of the shapes that count as a bingo, see WinPatterns.
+	WinPattern string `json:"winPattern,omitempty"`
+	// TotalRerolls are the rerolls every new board starts with.
+	TotalRerolls int `json:"totalRerolls"`
+	// JoinEmoji is the reaction players join the bingo with.
+	JoinEmoji string `json:"joinEmoji,omitempty"`
+	// RerollPolicy are the reroll rules of all boards.
+	RerollPolicy RerollPolicy `json:"rerollPolicy"`
+	// Events is the history of the bingo, oldest first.
+	Events []Event `json:"events"`
+	// Moderators are the users besides the owner that may mark fields.
+	Moderators []string `json:"moderators"`
+	// Webhooks receive the events of this bingo in addition to the webhooks of the guild.
+	Webhooks []Webhook `json:"webhooks,omitempty"`
+	// ApiKey authenticates the automation requests of the bingo.
+	ApiKey string `json:"apiKey,omitempty"`
+	// AutomationResults are the results of automation requests by their idempotency key.
+	AutomationResults map[string]AutomationResult `json:"automationResults,omitempty"`
+
+	lock sync.Mutex
 }
 
 type BingoBoard struct {
-	Content  []string `json:"content"`
-	Id       string   `json:"id"`
-	UserName string   `json:"username"`
-	Rerolls  int      `json:"rerolls"`
-	Password string   `json:"password"`
+	Content     []string `json:"content"`
+	Id          string   `json:"id"`
+	UserName    string   `json:"username"`
+	Rerolls     int      `json:"rerolls"`
+	RerollsUsed int      `json:"rerollsUsed"`
+	Password    string   `json:"password"`
+	Handicap    Handicap `json:"handicap"`
+	// LastReroll is the time of the last reroll or swap for the cooldown.
+	LastReroll time.Time `json:"lastReroll"`
+	// RegeneratedAt is the time the last reroll was regenerated.
+	RegeneratedAt time.Time `json:"regeneratedAt"`
+	// LinesRewarded is the number of lines the board already got bonus rerolls for.
+	LinesRewarded int `json:"linesRewarded"`
+	// RecentRerolls are the fields last rerolled away, oldest first.
+	RecentRerolls []string `json:"recentRerolls"`
+}
+
+// Winner records the moment a board completed its first line.
+type Winner struct {
+	BoardId string    `json:"boardID"`
+	Time    time.Time `json:"time"`
 }
 
 type Field struct {
@@ -39,42 +100,206 @@ var (
 	Bingos map[string]*Bingo
 )
 
+// Lock serializes the changes and renders of the bingo between discord, the web server and the twitch chat.
+func (b *Bingo) Lock() {
+	b.lock.Lock()
+}
+
+func (b *Bingo) Unlock() {
+	b.lock.Unlock()
+}
+
 func (b *Bingo) CheckFinished() []*BingoBoard {
 	finishedBoards := make([]*BingoBoard, 0)
-boardLoop:
 	for _, board := range b.Boards {
-		//Create done array
-		done := make([]bool, 25)
-		for k, cont := range board.Content {
-			done[k] = b.Completed[cont]
+		if b.CountLines(board) > 0 {
+			finishedBoards = append(finishedBoards, board)
 		}
+	}
 
-		//Check Rows
-		for r := 0; r < 5; r++ {
-			if done[0+r*5] && done[1+r*5] && done[2+r*5] && done[3+r*5] && done[4+r*5] {
-				finishedBoards = append(finishedBoards, board)
-				continue boardLoop
+	return finishedBoards
+}
+
+// CountLines returns the number of completed lines of the win pattern on the board.
+func (b *Bingo) CountLines(board *BingoBoard) int {
+	return len(b.CompletedLines(board))
+}
+
+// CompletedLines returns the cell indices of every completed line of the win pattern on the board.
+func (b *Bingo) CompletedLines(board *BingoBoard) [][]int {
+	completed := make([][]int, 0)
+lineLoop:
+	for _, line := range b.winLines(boardWidth(len(board.Content))) {
+		for _, index := range line {
+			if !b.IsDone(board.Content[index]) {
+				continue lineLoop
 			}
 		}
-		//Check Columns
-		for c := 0; c < 5; c++ {
-			if done[0+c] && done[5+c] && done[2*5+c] && done[3*5+c] && done[4*5+c] {
-				finishedBoards = append(finishedBoards, board)
-				continue boardLoop
+		completed = append(completed, line)
+	}
+	return completed
+}
+
+// OneAway reports whether a single open cell would complete a line on the board.
+func (b *Bingo) OneAway(board *BingoBoard) bool {
+	for _, line := range b.winLines(boardWidth(len(board.Content))) {
+		open := 0
+		for _, index := range line {
+			if !b.IsDone(board.Content[index]) {
+				open++
 			}
 		}
-		//Check Diagonals
-		if done[0] && done[6] && done[12] && done[18] && done[24] {
-			finishedBoards = append(finishedBoards, board)
-			continue boardLoop
+		if open == 1 {
+			return true
 		}
-		if done[4] && done[8] && done[12] && done[16] && done[20] {
-			finishedBoards = append(finishedBoards, board)
-			continue boardLoop
+	}
+	return false
+}
+
+// lines returns the cell indices of all rows, columns and diagonals of a board with the given width.
+func lines(width int) [][]int {
+	if width == 0 {
+		return nil
+	}
+
+	all := make([][]int, 0, 2*width+2)
+	diagonal := make([]int, 0, width)
+	antiDiagonal := make([]int, 0, width)
+	for i := 0; i < width; i++ {
+		row := make([]int, 0, width)
+		column := make([]int, 0, width)
+		for j := 0; j < width; j++ {
+			row = append(row, i*width+j)
+			column = append(column, j*width+i)
 		}
+		all = append(all, row, column)
+		diagonal = append(diagonal, i*width+i)
+		antiDiagonal = append(antiDiagonal, i*width+width-1-i)
 	}
+	return append(all, diagonal, antiDiagonal)
+}
 
-	return finishedBoards
+// IsDone reports whether a field on a board counts as completed.
+func (b *Bingo) IsDone(word string) bool {
+	return word == FreeField || b.Completed[word]
+}
+
+// boardWidth returns the number of fields per row of a square board with the given size.
+func boardWidth(size int) int {
+	return int(math.Sqrt(float64(size)))
+}
+
+// Width returns the number of cells per row the board is shown with. The last
+// row of a board that is not square is not full.
+func (board *BingoBoard) Width() int {
+	width := 1
+	for width*width < len(board.Content) {
+		width++
+	}
+	return width
+}
+
+// Finished reports whether every board of the bingo has a bingo.
+func (b *Bingo) Finished() bool {
+	for _, board := range b.Boards {
+		if b.Placement(board.Id) == 0 {
+			return false
+		}
+	}
+	return len(b.Boards) > 0
+}
+
+// CountCompleted returns the number of completed fields on the board.
+func (b *Bingo) CountCompleted(board *BingoBoard) int {
+	count := 0
+	for _, cont := range board.Content {
+		if b.IsDone(cont) {
+			count++
+		}
+	}
+	return count
+}
+
+// UpdateWinners records every board that has a bingo but is not yet listed in
+// Winners and returns the newly finished boards.
+func (b *Bingo) UpdateWinners() []*BingoBoard {
+	return b.UpdateWinnersAt(time.Now())
+}
+
+// UpdateWinnersAt is UpdateWinners for boards that finished at the given time, e.g. in a replayed match.
+func (b *Bingo) UpdateWinnersAt(at time.Time) []*BingoBoard {
+	newWinners := make([]*BingoBoard, 0)
+	for _, board := range b.CheckFinished() {
+		if b.Placement(board.Id) > 0 {
+			continue
+		}
+		b.Winners = append(b.Winners, Winner{BoardId: board.Id, Time: at})
+		b.Record(Event{Type: EventBingo, Time: at, BoardId: board.Id, UserName: board.UserName})
+		newWinners = append(newWinners, board)
+	}
+	if len(newWinners) > 0 && b.Finished() {
+		b.Record(Event{Type: EventGameEnded, Time: at})
+	}
+	return newWinners
+}
+
+// Placement returns the 1-based rank in which the board got its bingo or 0 if
+// it has none yet. Boards finishing with the same toggle share a placement.
+func (b *Bingo) Placement(boardId string) int {
+	placement := 0
+	var last time.Time
+	for i, winner := range b.Winners {
+		if i == 0 || !winner.Time.Equal(last) {
+			placement = i + 1
+			last = winner.Time
+		}
+		if winner.BoardId == boardId {
+			return placement
+		}
+	}
+	return 0
+}
+
+// Toggle flips the completion state of a field and returns the new state.
+// The second return value is false if the field is not part of the bingo.
+func (b *Bingo) Toggle(word string) (bool, bool) {
+	return b.ToggleAt(word, time.Now())
+}
+
+// ToggleAt is Toggle for a change that happened at the given time. The
+// completion time and the event of the field both get that time.
+func (b *Bingo) ToggleAt(word string, at time.Time) (bool, bool) {
+	if _, exists := b.Completed[word]; !exists {
+		return false, false
+	}
+
+	newValue := !b.Completed[word]
+	b.Completed[word] = newValue
+
+	if b.CompletedAt == nil {
+		b.CompletedAt = make(map[string]time.Time)
+	}
+	if newValue {
+		b.CompletedAt[word] = at
+		b.Record(Event{Type: EventFieldCompleted, Time: at, Field: word})
+	} else {
+		delete(b.CompletedAt, word)
+		b.Record(Event{Type: EventFieldReset, Time: at, Field: word})
+	}
+	return newValue, true
+}
+
+// CanModerate reports whether the user may mark fields of the bingo.
+func (b *Bingo) CanModerate(userId string) bool {
+	return userId == b.OwnerId || contains(b.Moderators, userId)
+}
+
+// RecordReroll counts a reroll of the field for the field statistics.
+func (b *Bingo) RecordReroll(word string) {
+	if b.Rerolled == nil {
+		b.Rerolled = make(map[string]int)
+	}
+	b.Rerolled[word]++
 }
 
 func AddBingo(bin *Bingo) {
@@ -83,43 +308,173 @@ func AddBingo(bin *Bingo) {
 	Bingos[id] = bin
 }
 
-func Create(guildId, ownerId string, _kind string, _size int) (*Bingo, error) {
-	bin := Bingo{
-		OwnerId:  ownerId,
-		GuildId:  guildId,
-		Kind:     _kind,
-		Size:     _size,
-		Id:       random.RandSeq(16),
-		Boards:   make(map[string]*BingoBoard),
-		Password: random.RandSeq(8),
+// Latest returns the most recently created running bingo of the guild or nil if there is none.
+func Latest(guildId string) *Bingo {
+	var latest *Bingo
+	for _, bin := range Bingos {
+		if bin.GuildId != guildId {
+			continue
+		}
+		if latest == nil || bin.Created.After(latest.Created) {
+			latest = bin
+		}
+	}
+	return latest
+}
+
+// Get returns the running bingo with the given id or loads it from the storage path.
+func Get(path string, id string) (*Bingo, error) {
+	bin, exists := Bingos[id]
+	if exists {
+		return bin, nil
+	}
+
+	return Load(path, id)
+}
+
+// Load reads a stored bingo by its id without adding it to the running bingos.
+func Load(path string, id string) (*Bingo, error) {
+	if id == "" || strings.ContainsAny(id, "*?[]\\/.") {
+		return nil, errors.New("invalid bingo id")
 	}
 
-	wordsFile, err := ioutil.ReadFile("bingos/" + _kind + ".txt")
+	matches, err := filepath.Glob(path + "*_" + id + ".json")
 	if err != nil {
 		return nil, err
 	}
+	if len(matches) == 0 {
+		return nil, errors.New("bingo " + id + " not found")
+	}
 
-	words := strings.Split(string(wordsFile), "\n")
-	bin.Completed = make(map[string]bool)
-	bin.Words = make([]string, 0)
+	return loadFile(matches[0])
+}
 
-	for _, word := range words {
-		word = strings.TrimSpace(word)
-		if word[0] == '#' {
-			continue
+// LoadAll reads every bingo stored in the storage path.
+func LoadAll(path string) ([]*Bingo, error) {
+	files, err := filepath.Glob(path + "*_*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	bins := make([]*Bingo, 0, len(files))
+	for _, file := range files {
+		bin, err := loadFile(file)
+		if err != nil {
+			return nil, err
 		}
+		bins = append(bins, bin)
+	}
+	return bins, nil
+}
+
+func loadFile(file string) (*Bingo, error) {
+	jsonBingo, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return Decode(jsonBingo)
+}
+
+// Decode parses a stored bingo. Bingos stored before they had their own win pattern,
+// rerolls and join emoji get the current ones of their guild.
+func Decode(jsonBingo []byte) (*Bingo, error) {
+	bin := &Bingo{}
+	err := json.Unmarshal(jsonBingo, bin)
+	if err != nil {
+		return nil, err
+	}
 
-		bin.Words = append(bin.Words, word)
+	guild := Guild(bin.GuildId)
+	if bin.WinPattern == "" {
+		bin.WinPattern = DefaultWinPattern
+		bin.TotalRerolls = guild.Rerolls()
+	}
+	if bin.JoinEmoji == "" {
+		bin.JoinEmoji = guild.JoinEmoji
+	}
+	return bin, nil
+}
+
+// Create starts a new bingo of the kind with the game settings of the config.
+// The settings of the guild provide the defaults for an empty kind, a size of 0,
+// the rerolls and the win pattern.
+func Create(cfg *config.Config, guildId, ownerId string, _kind string, _size int) (*Bingo, error) {
+	guild := Guild(guildId)
+	if _kind == "" {
+		_kind = guild.DefaultKind
+	}
+	if _kind == "" {
+		return nil, errors.New("no bingo type given and the server has no default")
+	}
+	if _size == 0 {
+		_size = guild.BoardWidth * guild.BoardWidth
+	}
+
+	bin := Bingo{
+		OwnerId:             ownerId,
+		GuildId:             guildId,
+		Kind:                _kind,
+		Size:                _size,
+		Id:                  random.RandSeq(16),
+		Boards:              make(map[string]*BingoBoard),
+		Password:            random.RandSeq(8),
+		Created:             time.Now(),
+		DifficultyTolerance: cfg.GameSettings.DifficultyTolerance,
+		WinPattern:          guild.WinPattern,
+		TotalRerolls:        cfg.GameSettings.TotalRerolls,
+		JoinEmoji:           guild.JoinEmoji,
+	}
+	if guild.TotalRerolls != nil {
+		bin.TotalRerolls = *guild.TotalRerolls
+	}
+	if cfg.GameSettings.ProtectSpectators {
+		bin.SpectatorKey = random.RandSeq(8)
+	}
+	_, err := bin.EnsureApiKey()
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := PolicyByName(cfg.GameSettings, cfg.GameSettings.DefaultRerollPolicy)
+	if err == nil {
+		bin.RerollPolicy = policy
+	}
+
+	words := CurrentCatalog()
+	if words == nil {
+		words, err = LoadCatalog(cfg.WordsPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	list, exists := words.Lists[_kind]
+	if !exists {
+		return nil, errors.New("unknown bingo type " + _kind)
+	}
+	if len(list.Words) < _size {
+		return nil, errors.New("the word list " + _kind + " has fewer than " + strconv.Itoa(_size) + " fields")
+	}
+
+	// The bingo gets its own copy as hosts can change the fields of a running bingo
+	bin.Words = append([]string(nil), list.Words...)
+	bin.Difficulty = make(map[string]int, len(list.Difficulty))
+	for word, difficulty := range list.Difficulty {
+		bin.Difficulty[word] = difficulty
+	}
+	bin.Completed = make(map[string]bool)
+	for _, word := range bin.Words {
 		bin.Completed[word] = false
 	}
 
 	bin.Wordsize = len(bin.Words)
 
-	bin.Store(config.Json.StoragePath)
+	bin.Store(cfg.StoragePath)
 	return &bin, nil
 }
 
-func (bin *Bingo) CreateBoard(id string, username string, totalRerolls int) *BingoBoard {
+// CreateBoard adds the board of a player with the rerolls of the bingo. An existing board is returned unchanged.
+func (bin *Bingo) CreateBoard(id string, username string) *BingoBoard {
 	existingBoard, exists := bin.Boards[id]
 	if exists {
 		return existingBoard
@@ -127,24 +482,70 @@ func (bin *Bingo) CreateBoard(id string, username string, totalRerolls int) *Bin
 
 	board := &BingoBoard{}
 	board.Password = random.RandSeq(8)
-	board.Rerolls = totalRerolls
+	board.Rerolls = bin.TotalRerolls
+	board.RegeneratedAt = now()
 
-	board.Content = make([]string, 0, bin.Size)
+	board.Content = bin.generateContent(bin.TargetDifficulty)
+
+	board.Id = id
+	board.UserName = username
+
+	bin.Boards[board.Id] = board
+	bin.Record(Event{Type: EventPlayerJoined, BoardId: board.Id, UserName: board.UserName})
+	return board
+}
+
+// generateContent picks the fields of a new board. If target is greater than 0
+// the board is balanced to that average field difficulty.
+func (bin *Bingo) generateContent(target int) []string {
+	content := make([]string, 0, bin.Size)
 	for i := 0; i < bin.Size; i++ {
+		if bin.Weights != nil {
+			content = append(content, bin.weightedField(content))
+			continue
+		}
 		randomField := bin.Words[rand.Intn(bin.Wordsize)]
-		for contains(board.Content, randomField) {
+		for contains(content, randomField) {
 			randomField = bin.Words[rand.Intn(bin.Wordsize)]
 		}
-		board.Content = append(board.Content, randomField)
+		content = append(content, randomField)
+	}
+	if target > 0 {
+		bin.balance(content, target)
 	}
+	return content
+}
 
-	board.Id = id
-	board.UserName = username
+// weightedField picks a random field that is not in exclude according to Weights.
+func (bin *Bingo) weightedField(exclude []string) string {
+	total := 0.0
+	for _, word := range bin.Words {
+		if !contains(exclude, word) {
+			total += bin.weight(word)
+		}
+	}
 
-	bin.Boards[board.Id] = board
+	pick := rand.Float64() * total
+	last := ""
+	for _, word := range bin.Words {
+		if contains(exclude, word) {
+			continue
+		}
+		last = word
+		pick -= bin.weight(word)
+		if pick < 0 {
+			return word
+		}
+	}
+	return last
+}
 
-	bin.Store(config.Json.StoragePath)
-	return board
+func (bin *Bingo) weight(word string) float64 {
+	weight, exists := bin.Weights[word]
+	if !exists {
+		return 1
+	}
+	return weight
 }
 
 func contains(array []string, val string) bool {
@@ -2,14 +2,130 @@ package bingo
 
 import (
 	"Bingo/config"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestCreateBoard(t *testing.T) {
-	bin, err := Create("12345", "", "valorant", 5)
+	cfg := config.Default()
+	cfg.WordsPath = "../bingos/"
+	cfg.StoragePath = t.TempDir() + "/"
+	bin, err := Create(cfg, "12345", "", "valorant", 5)
 
 	if err != nil {
-		t.Fail()
+		t.Fatal(err)
+	}
+	board := bin.CreateBoard("reandomid", "user")
+	if board.Rerolls != cfg.GameSettings.TotalRerolls {
+		t.Errorf("expected %d rerolls, got %d", cfg.GameSettings.TotalRerolls, board.Rerolls)
+	}
+}
+
+func TestDecodeOldBingo(t *testing.T) {
+	SetDefaultRerolls(3)
+	t.Cleanup(func() { SetDefaultRerolls(0) })
+
+	old, err := Decode([]byte(`{"id":"old","guildID":"12345","size":9}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old.WinPattern != DefaultWinPattern || old.TotalRerolls != 3 || old.JoinEmoji != DefaultJoinEmoji {
+		t.Errorf("expected the defaults of the guild, got %s, %d rerolls and %s", old.WinPattern, old.TotalRerolls, old.JoinEmoji)
+	}
+
+	current, err := Decode([]byte(`{"id":"new","guildID":"12345","winPattern":"corners","totalRerolls":0,"joinEmoji":"🎲"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.WinPattern != "corners" || current.TotalRerolls != 0 || current.JoinEmoji != "🎲" {
+		t.Errorf("expected the stored settings to be kept, got %s, %d rerolls and %s", current.WinPattern, current.TotalRerolls, current.JoinEmoji)
+	}
+}
+
+func TestWinPattern(t *testing.T) {
+	bin := &Bingo{
+		Completed:  map[string]bool{"a": true, "c": true, "g": true, "i": true},
+		WinPattern: "corners",
+	}
+	board := &BingoBoard{Content: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"}}
+
+	if bin.CountLines(board) != 1 {
+		t.Errorf("expected the corners to count as bingo")
+	}
+	bin.WinPattern = DefaultWinPattern
+	if bin.CountLines(board) != 0 {
+		t.Errorf("expected no completed line")
+	}
+}
+
+func TestResolveEvent(t *testing.T) {
+	bin := &Bingo{Kind: "valorant", Completed: map[string]bool{"Ace": false, "1v3 Clutch": false}}
+	aliases := map[string]map[string]string{"valorant": {"clutch_1v3": "1v3 Clutch", "teamkill": "Teamkill"}}
+
+	for event, expected := range map[string]string{"clutch_1v3": "1v3 Clutch", "ACE": "Ace", " ace ": "Ace"} {
+		field, err := bin.ResolveEvent(aliases, event)
+		if err != nil || field != expected {
+			t.Errorf("expected %s for %q, got %q: %v", expected, event, field, err)
+		}
+	}
+	for _, event := range []string{"teamkill", "unknown", ""} {
+		if _, err := bin.ResolveEvent(aliases, event); err == nil {
+			t.Errorf("expected an error for %q", event)
+		}
+	}
+}
+
+func TestRememberAutomationResult(t *testing.T) {
+	bin := &Bingo{}
+	start := time.Now()
+	for i := 0; i <= maxAutomationResults; i++ {
+		bin.RememberAutomationResult(strconv.Itoa(i), AutomationResult{Time: start.Add(time.Duration(i) * time.Second)})
+	}
+	if len(bin.AutomationResults) != maxAutomationResults {
+		t.Errorf("expected %d results, got %d", maxAutomationResults, len(bin.AutomationResults))
+	}
+	if _, exists := bin.AutomationResultFor("0"); exists {
+		t.Errorf("expected the oldest key to be forgotten")
+	}
+}
+
+func TestWeightedFields(t *testing.T) {
+	bin := &Bingo{
+		Words:    []string{"a", "b", "c", "never"},
+		Wordsize: 4,
+		Size:     3,
+		Weights:  map[string]float64{"a": 2, "never": 0},
+	}
+	for i := 0; i < 50; i++ {
+		content := bin.generateContent(0)
+		if len(content) != 3 || contains(content, "never") {
+			t.Fatalf("expected the fields with weight, got %v", content)
+		}
+	}
+}
+
+func TestCreateAnonymousBoards(t *testing.T) {
+	bin, _ := newTestBingo(30, 9)
+
+	boards, err := bin.CreateAnonymousBoards(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	boards, err = bin.CreateAnonymousBoards(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bin.Boards) != 6 || len(boards) != 2 {
+		t.Fatalf("expected the player and 5 cards, got %d boards", len(bin.Boards))
+	}
+	if !boards[0].IsAnonymous() || boards[1].UserName != "Card 5" || bin.Boards["player"].IsAnonymous() {
+		t.Errorf("expected numbered anonymous cards, got %s", boards[1].UserName)
+	}
+
+	for _, count := range []int{0, MaxAnonymousCards + 1} {
+		if _, err = bin.CreateAnonymousBoards(count); err == nil {
+			t.Errorf("expected an error for %d cards", count)
+		}
 	}
-	bin.CreateBoard("reandomid", "user", config.Json.GameSettings.TotalRerolls)
 }
@@ -0,0 +1,49 @@
+package bingo
+
+import (
+	"Bingo/random"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	// AnonymousPrefix starts the id of every board that does not belong to a discord user.
+	AnonymousPrefix = "card-"
+	// MaxAnonymousCards is the most cards created at once, a few printed pages.
+	MaxAnonymousCards = 64
+)
+
+// IsAnonymous reports whether the board is a printed card without a discord user.
+func (b *BingoBoard) IsAnonymous() bool {
+	return strings.HasPrefix(b.Id, AnonymousPrefix)
+}
+
+// CreateAnonymousBoards adds count boards without discord users, e.g. for printed cards.
+// The random part of the board id identifies the card when a winner is verified.
+func (bin *Bingo) CreateAnonymousBoards(count int) ([]*BingoBoard, error) {
+	if count <= 0 {
+		return nil, errors.New("the number of cards must be positive")
+	}
+	if count > MaxAnonymousCards {
+		return nil, errors.New("at most " + strconv.Itoa(MaxAnonymousCards) + " cards can be created at once")
+	}
+
+	number := 0
+	for _, board := range bin.Boards {
+		if board.IsAnonymous() {
+			number++
+		}
+	}
+
+	boards := make([]*BingoBoard, 0, count)
+	for len(boards) < count {
+		id := AnonymousPrefix + random.RandSeq(8)
+		if _, exists := bin.Boards[id]; exists {
+			continue
+		}
+		number++
+		boards = append(boards, bin.CreateBoard(id, "Card "+strconv.Itoa(number)))
+	}
+	return boards, nil
+}
@@ -0,0 +1,109 @@
+package bingo
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// WordList is the parsed word list of a bingo type.
+type WordList struct {
+	Words      []string
+	Difficulty map[string]int
+}
+
+// Catalog holds the word lists of all bingo types. A catalog is never
+// modified after loading, reloading replaces it as a whole.
+type Catalog struct {
+	Path  string
+	Lists map[string]WordList
+}
+
+var catalog atomic.Pointer[Catalog]
+
+// LoadCatalog reads every word list in the directory. Each "<kind>.txt" is a bingo type.
+func LoadCatalog(path string) (*Catalog, error) {
+	files, err := filepath.Glob(filepath.Join(path, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no word lists found in " + path)
+	}
+
+	c := &Catalog{Path: path, Lists: make(map[string]WordList)}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		kind := strings.TrimSuffix(filepath.Base(file), ".txt")
+		words, difficulty := parseWords(string(content))
+		if len(words) == 0 {
+			return nil, errors.New("word list " + kind + " has no fields")
+		}
+		c.Lists[kind] = WordList{Words: words, Difficulty: difficulty}
+	}
+	return c, nil
+}
+
+// CurrentCatalog returns the catalog new bingos are created from or nil if none was set.
+func CurrentCatalog() *Catalog {
+	return catalog.Load()
+}
+
+// SetCatalog atomically replaces the catalog for all bingos created afterwards.
+func SetCatalog(c *Catalog) {
+	catalog.Store(c)
+}
+
+// Kinds returns the names of all bingo types in alphabetical order.
+func (c *Catalog) Kinds() []string {
+	kinds := make([]string, 0, len(c.Lists))
+	for kind := range c.Lists {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Diff describes the bingo types and fields that changed compared to the old catalog.
+func (c *Catalog) Diff(old *Catalog) []string {
+	changes := make([]string, 0)
+	for _, kind := range c.Kinds() {
+		oldList, exists := old.Lists[kind]
+		if !exists {
+			changes = append(changes, "added "+kind+" with "+strconv.Itoa(len(c.Lists[kind].Words))+" fields")
+			continue
+		}
+
+		list := c.Lists[kind]
+		added, removed, changed := 0, 0, 0
+		for _, word := range list.Words {
+			if !contains(oldList.Words, word) {
+				added++
+			} else if list.Difficulty[word] != oldList.Difficulty[word] {
+				changed++
+			}
+		}
+		for _, word := range oldList.Words {
+			if !contains(list.Words, word) {
+				removed++
+			}
+		}
+		if added+removed+changed > 0 {
+			changes = append(changes, kind+": "+strconv.Itoa(added)+" fields added, "+
+				strconv.Itoa(removed)+" removed, "+strconv.Itoa(changed)+" difficulties changed")
+		}
+	}
+	for _, kind := range old.Kinds() {
+		if _, exists := c.Lists[kind]; !exists {
+			changes = append(changes, "removed "+kind)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,169 @@
+package bingo
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultDifficulty is used for fields without a difficulty in the word list.
+	DefaultDifficulty = 3
+	MinDifficulty     = 1
+	MaxDifficulty     = 5
+
+	maxBalanceSteps = 1000
+)
+
+// DifficultyLevels maps the levels selectable on /create to the average field difficulty of a board.
+var DifficultyLevels = map[string]int{
+	"easy":   2,
+	"normal": 3,
+	"hard":   4,
+}
+
+// ParseDifficulty returns the target difficulty of a level. An empty level disables balancing.
+func ParseDifficulty(level string) (int, error) {
+	if level == "" {
+		return 0, nil
+	}
+
+	difficulty, exists := DifficultyLevels[level]
+	if !exists {
+		return 0, errors.New("unknown difficulty level " + level)
+	}
+	return difficulty, nil
+}
+
+// parseWords reads a word list. Every line holds one field, optionally
+// followed by "|" and its difficulty from 1 to 5, e.g. "Ace | 5".
+// Empty lines and lines starting with # are ignored.
+func parseWords(content string) ([]string, map[string]int) {
+	words := make([]string, 0)
+	difficulty := make(map[string]int)
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		word := line
+		separator := strings.LastIndex(line, "|")
+		if separator >= 0 {
+			word = strings.TrimSpace(line[:separator])
+			value, err := strconv.Atoi(strings.TrimSpace(line[separator+1:]))
+			if err == nil && value >= MinDifficulty && value <= MaxDifficulty {
+				difficulty[word] = value
+			}
+		}
+		if word == "" || contains(words, word) {
+			continue
+		}
+
+		words = append(words, word)
+	}
+
+	return words, difficulty
+}
+
+// FieldDifficulty returns the difficulty of a field.
+func (b *Bingo) FieldDifficulty(word string) int {
+	difficulty, exists := b.Difficulty[word]
+	if !exists {
+		return DefaultDifficulty
+	}
+	return difficulty
+}
+
+// BoardDifficulty returns the sum of the difficulties of all fields on the board.
+func (b *Bingo) BoardDifficulty(board *BingoBoard) int {
+	return b.sumDifficulty(board.Content)
+}
+
+func (b *Bingo) sumDifficulty(content []string) int {
+	sum := 0
+	for _, word := range content {
+		sum += b.FieldDifficulty(word)
+	}
+	return sum
+}
+
+// balance swaps fields of the content with unused words until the difficulty
+// sum is within the configured tolerance of target per field and then orders
+// the fields so that every row has a similar difficulty.
+func (b *Bingo) balance(content []string, target int) {
+	goal := target * len(content)
+	tolerance := b.DifficultyTolerance
+
+	unused := make([]string, 0, len(b.Words))
+	for _, word := range b.Words {
+		if !contains(content, word) {
+			unused = append(unused, word)
+		}
+	}
+
+	sum := b.sumDifficulty(content)
+	for step := 0; step < maxBalanceSteps && len(unused) > 0; step++ {
+		distance := abs(sum - goal)
+		if distance <= tolerance {
+			break
+		}
+
+		cell := rand.Intn(len(content))
+		candidate := rand.Intn(len(unused))
+		newSum := sum - b.FieldDifficulty(content[cell]) + b.FieldDifficulty(unused[candidate])
+		if abs(newSum-goal) >= distance {
+			continue
+		}
+
+		content[cell], unused[candidate] = unused[candidate], content[cell]
+		sum = newSum
+	}
+
+	b.balanceRows(content)
+}
+
+// balanceRows distributes the fields over the rows of the board, always
+// putting the hardest remaining field into the row with the lowest sum.
+func (b *Bingo) balanceRows(content []string) {
+	width := boardWidth(len(content))
+	if width == 0 || width*width != len(content) {
+		return
+	}
+
+	fields := make([]string, len(content))
+	copy(fields, content)
+	rand.Shuffle(len(fields), func(i, j int) { fields[i], fields[j] = fields[j], fields[i] })
+	sort.SliceStable(fields, func(i, j int) bool {
+		return b.FieldDifficulty(fields[i]) > b.FieldDifficulty(fields[j])
+	})
+
+	rows := make([][]string, width)
+	sums := make([]int, width)
+	for _, field := range fields {
+		row := -1
+		for r := range rows {
+			if len(rows[r]) < width && (row < 0 || sums[r] < sums[row]) {
+				row = r
+			}
+		}
+		rows[row] = append(rows[row], field)
+		sums[row] += b.FieldDifficulty(field)
+	}
+
+	rand.Shuffle(len(rows), func(i, j int) { rows[i], rows[j] = rows[j], rows[i] })
+	for r, row := range rows {
+		rand.Shuffle(len(row), func(i, j int) { row[i], row[j] = row[j], row[i] })
+		copy(content[r*width:], row)
+	}
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,78 @@
+package bingo
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParseWords(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		words      []string
+		difficulty map[string]int
+	}{
+		{"plain", "Ace\nThrifty\n", []string{"Ace", "Thrifty"}, map[string]int{}},
+		{"difficulty", "Ace | 5\nThrifty|1", []string{"Ace", "Thrifty"}, map[string]int{"Ace": 5, "Thrifty": 1}},
+		{"out of range", "Ace | 6\nThrifty | 0", []string{"Ace", "Thrifty"}, map[string]int{}},
+		{"not a number", "Ace | hard", []string{"Ace"}, map[string]int{}},
+		{"last separator", "1 | 2 | 3", []string{"1 | 2"}, map[string]int{"1 | 2": 3}},
+		{"comments and empty lines", "# fields\n\n  Ace  \n", []string{"Ace"}, map[string]int{}},
+		{"duplicates", "Ace | 2\nAce | 4", []string{"Ace"}, map[string]int{"Ace": 4}},
+		{"no field", " | 3", []string{}, map[string]int{"": 3}},
+	}
+	for _, test := range tests {
+		words, difficulty := parseWords(test.content)
+		if !reflect.DeepEqual(words, test.words) {
+			t.Errorf("%s: expected the words %q, got %q", test.name, test.words, words)
+		}
+		if !reflect.DeepEqual(difficulty, test.difficulty) {
+			t.Errorf("%s: expected the difficulties %v, got %v", test.name, test.difficulty, difficulty)
+		}
+	}
+}
+
+func TestParseDifficulty(t *testing.T) {
+	for level, expected := range map[string]int{"": 0, "easy": 2, "hard": 4} {
+		if difficulty, err := ParseDifficulty(level); err != nil || difficulty != expected {
+			t.Errorf("expected %d for %q, got %d: %v", expected, level, difficulty, err)
+		}
+	}
+	if _, err := ParseDifficulty("impossible"); err == nil {
+		t.Errorf("expected an error for an unknown level")
+	}
+}
+
+func TestBalance(t *testing.T) {
+	bin := &Bingo{Size: 9, Difficulty: make(map[string]int), DifficultyTolerance: 1}
+	for i := 0; i < 20; i++ {
+		word := "easy" + strconv.Itoa(i)
+		bin.Words = append(bin.Words, word, "hard"+strconv.Itoa(i))
+		bin.Difficulty[word] = 1
+		bin.Difficulty["hard"+strconv.Itoa(i)] = 5
+	}
+	bin.Wordsize = len(bin.Words)
+
+	for _, target := range []int{2, 4} {
+		content := bin.generateContent(target)
+		if sum := bin.sumDifficulty(content); abs(sum-target*len(content)) > bin.DifficultyTolerance {
+			t.Errorf("expected a difficulty sum of %d±1, got %d for %v", target*len(content), sum, content)
+		}
+
+		// The rows differ by at most one hard field
+		minRow, maxRow := 100, 0
+		for row := 0; row < 3; row++ {
+			sum := bin.sumDifficulty(content[row*3 : row*3+3])
+			if sum < minRow {
+				minRow = sum
+			}
+			if sum > maxRow {
+				maxRow = sum
+			}
+		}
+		if maxRow-minRow > 4 {
+			t.Errorf("expected rows of similar difficulty, got %v", content)
+		}
+	}
+}
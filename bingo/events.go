@@ -0,0 +1,100 @@
+package bingo
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	EventPlayerJoined     = "playerJoined"
+	EventFieldCompleted   = "fieldCompleted"
+	EventFieldReset       = "fieldReset"
+	EventRerolled         = "rerolled"
+	EventSwapped          = "swapped"
+	EventBingo            = "bingo"
+	EventHandicapSet      = "handicapSet"
+	EventWordReplaced     = "wordReplaced"
+	EventBoardRegenerated = "boardRegenerated"
+	EventPlayerRemoved    = "playerRemoved"
+	EventWordsAdded       = "wordsAdded"
+	// EventGameEnded is recorded when the last board got its bingo.
+	EventGameEnded = "gameEnded"
+)
+
+// Event records a change to a running bingo.
+type Event struct {
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	BoardId  string    `json:"boardID,omitempty"`
+	UserName string    `json:"username,omitempty"`
+	Field    string    `json:"field,omitempty"`
+	NewField string    `json:"newField,omitempty"`
+}
+
+var (
+	listeners     = make([]func(bin *Bingo, event Event), 0)
+	listenersLock sync.Mutex
+)
+
+// Listen registers a function that is called with every recorded event. It is
+// called while the bingo is changed and must not block.
+func Listen(listener func(bin *Bingo, event Event)) {
+	listenersLock.Lock()
+	listeners = append(listeners, listener)
+	listenersLock.Unlock()
+}
+
+// Record appends an event to the history of the bingo and passes it to the listeners.
+func (b *Bingo) Record(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	b.Events = append(b.Events, event)
+
+	listenersLock.Lock()
+	current := listeners
+	listenersLock.Unlock()
+	for _, listener := range current {
+		listener(b, event)
+	}
+}
+
+// RecentEvents returns the last count events, newest first.
+func (b *Bingo) RecentEvents(count int) []Event {
+	events := make([]Event, 0, count)
+	for i := len(b.Events) - 1; i >= 0 && len(events) < count; i-- {
+		events = append(events, b.Events[i])
+	}
+	return events
+}
+
+// String describes the event for feeds and chat messages.
+func (e Event) String() string {
+	switch e.Type {
+	case EventPlayerJoined:
+		return e.UserName + " joined"
+	case EventFieldCompleted:
+		return e.Field + " happened"
+	case EventFieldReset:
+		return e.Field + " was reset"
+	case EventRerolled:
+		return e.UserName + " rerolled " + e.Field + " into " + e.NewField
+	case EventSwapped:
+		return e.UserName + " swapped " + e.Field + " and " + e.NewField
+	case EventBingo:
+		return "BINGO for " + e.UserName + "!"
+	case EventHandicapSet:
+		return e.UserName + " got a handicap: " + e.Field
+	case EventWordReplaced:
+		return e.Field + " was replaced by " + e.NewField
+	case EventBoardRegenerated:
+		return e.UserName + " got a new board"
+	case EventPlayerRemoved:
+		return e.UserName + " was removed"
+	case EventWordsAdded:
+		return e.Field + " was added"
+	case EventGameEnded:
+		return "Every player has a BINGO, the game is over!"
+	}
+	return e.Type
+}
@@ -0,0 +1,162 @@
+package bingo
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultBoardWidth is the number of fields per row if the guild does not set one.
+	DefaultBoardWidth = 5
+	MinBoardWidth     = 3
+	MaxBoardWidth     = 7
+	// DefaultJoinEmoji is the reaction players join a bingo with.
+	DefaultJoinEmoji = "🎫"
+)
+
+// GuildSettings are the defaults of a discord server for its bingos.
+// Empty values fall back to the global config.
+type GuildSettings struct {
+	GuildId     string `json:"guildID"`
+	DefaultKind string `json:"defaultKind"`
+	// BoardWidth is the number of fields per row of new boards.
+	BoardWidth int `json:"boardWidth"`
+	// TotalRerolls overrides gameSettings.totalRerolls if set.
+	TotalRerolls *int   `json:"totalRerolls,omitempty"`
+	WinPattern   string `json:"winPattern"`
+	// AnnouncementChannel receives the bingo announcements instead of the channel the bingo was created in.
+	AnnouncementChannel string `json:"announcementChannel"`
+	JoinEmoji           string `json:"joinEmoji"`
+	// DisableSound stops the bot from playing the bingo sound in voice channels.
+	DisableSound bool `json:"disableSound"`
+	// Sounds maps the sound events to the sound played in the guild.
+	Sounds map[string]string `json:"sounds,omitempty"`
+	// KindSounds overrides Sounds for single bingo types.
+	KindSounds map[string]map[string]string `json:"kindSounds,omitempty"`
+	// ModeratorRoles may mark fields in every bingo of the guild.
+	ModeratorRoles []string `json:"moderatorRoles"`
+	// Webhooks receive the events of every bingo of the guild.
+	Webhooks []Webhook `json:"webhooks,omitempty"`
+}
+
+var (
+	guildSettings     = make(map[string]GuildSettings)
+	guildSettingsLock sync.Mutex
+	// defaultRerolls is gameSettings.totalRerolls of the current config.
+	defaultRerolls atomic.Int64
+)
+
+// SetDefaultRerolls sets the rerolls of the guilds without their own, it is called whenever the config is loaded.
+func SetDefaultRerolls(rerolls int) {
+	defaultRerolls.Store(int64(rerolls))
+}
+
+// Guild returns the settings of the guild with all unset values filled with their defaults.
+func Guild(guildId string) GuildSettings {
+	guildSettingsLock.Lock()
+	settings, exists := guildSettings[guildId]
+	guildSettingsLock.Unlock()
+	if !exists {
+		settings = GuildSettings{GuildId: guildId}
+	}
+
+	if settings.BoardWidth == 0 {
+		settings.BoardWidth = DefaultBoardWidth
+	}
+	if settings.WinPattern == "" {
+		settings.WinPattern = DefaultWinPattern
+	}
+	if settings.JoinEmoji == "" {
+		settings.JoinEmoji = DefaultJoinEmoji
+	}
+	return settings
+}
+
+// Rerolls returns the rerolls new boards of the guild start with.
+func (g GuildSettings) Rerolls() int {
+	if g.TotalRerolls != nil {
+		return *g.TotalRerolls
+	}
+	return int(defaultRerolls.Load())
+}
+
+// IsModerator reports whether one of the roles is a moderator role of the guild.
+func (g GuildSettings) IsModerator(roles []string) bool {
+	for _, role := range roles {
+		if contains(g.ModeratorRoles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the values that do not depend on discord.
+func (g GuildSettings) Validate() error {
+	if g.BoardWidth != 0 && (g.BoardWidth < MinBoardWidth || g.BoardWidth > MaxBoardWidth) {
+		return errors.New("the board size must be between " + strconv.Itoa(MinBoardWidth) + " and " + strconv.Itoa(MaxBoardWidth))
+	}
+	if g.TotalRerolls != nil && *g.TotalRerolls < 0 {
+		return errors.New("rerolls must not be negative")
+	}
+	if _, exists := WinPatterns[g.WinPattern]; g.WinPattern != "" && !exists {
+		return errors.New("unknown win pattern " + g.WinPattern)
+	}
+	return nil
+}
+
+// SetGuild validates and stores the settings of a guild.
+func SetGuild(path string, settings GuildSettings) error {
+	err := settings.Validate()
+	if err != nil {
+		return err
+	}
+
+	jsonSettings, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(path+"guilds/", 0755)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path+"guilds/"+settings.GuildId+".json", jsonSettings, 0644)
+	if err != nil {
+		return err
+	}
+
+	guildSettingsLock.Lock()
+	guildSettings[settings.GuildId] = settings
+	guildSettingsLock.Unlock()
+	return nil
+}
+
+// LoadGuilds reads the stored settings of all guilds.
+func LoadGuilds(path string) error {
+	files, err := filepath.Glob(path + "guilds/*.json")
+	if err != nil {
+		return err
+	}
+
+	guildSettingsLock.Lock()
+	defer guildSettingsLock.Unlock()
+	for _, file := range files {
+		jsonSettings, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		settings := GuildSettings{}
+		err = json.Unmarshal(jsonSettings, &settings)
+		if err != nil {
+			return err
+		}
+		guildSettings[settings.GuildId] = settings
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package bingo
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+// FreeField is the content of a pre-completed cell given as handicap.
+const FreeField = "Free"
+
+// Handicap gives a player an edge over the rest of the bingo.
+type Handicap struct {
+	// ExtraRerolls are granted on top of the configured rerolls.
+	ExtraRerolls int `json:"extraRerolls"`
+	// FreeCells is the number of pre-completed cells on the board.
+	FreeCells int `json:"freeCells"`
+	// Difficulty overrides the target difficulty of the bingo for this board. 0 keeps the bingo default.
+	Difficulty int `json:"difficulty"`
+}
+
+// String describes the handicap for display next to the board.
+func (h Handicap) String() string {
+	text := ""
+	if h.ExtraRerolls != 0 {
+		text += "+" + strconv.Itoa(h.ExtraRerolls) + " rerolls, "
+	}
+	if h.FreeCells != 0 {
+		text += strconv.Itoa(h.FreeCells) + " free, "
+	}
+	if h.Difficulty != 0 {
+		text += DifficultyName(h.Difficulty) + ", "
+	}
+	if text == "" {
+		return ""
+	}
+	return text[:len(text)-2]
+}
+
+// DifficultyName returns the level name of a target difficulty.
+func DifficultyName(difficulty int) string {
+	for name, value := range DifficultyLevels {
+		if value == difficulty {
+			return name
+		}
+	}
+	return "difficulty " + strconv.Itoa(difficulty)
+}
+
+// SetHandicap applies a new handicap to the board. A changed difficulty
+// generates new fields for the board, free cells replace its hardest open fields.
+func (b *Bingo) SetHandicap(board *BingoBoard, handicap Handicap) error {
+	if handicap.ExtraRerolls < 0 || handicap.FreeCells < 0 {
+		return errors.New("handicap values must not be negative")
+	}
+	if handicap.FreeCells >= len(board.Content) {
+		return errors.New("too many free cells")
+	}
+
+	board.Rerolls += handicap.ExtraRerolls - board.Handicap.ExtraRerolls
+	if board.Rerolls < 0 {
+		board.Rerolls = 0
+	}
+
+	if handicap.Difficulty != board.Handicap.Difficulty {
+		target := handicap.Difficulty
+		if target == 0 {
+			target = b.TargetDifficulty
+		}
+		board.Content = b.generateContent(target)
+		board.Handicap.FreeCells = 0
+	}
+
+	b.setFreeCells(board, handicap.FreeCells)
+
+	board.Handicap = handicap
+	b.Record(Event{Type: EventHandicapSet, BoardId: board.Id, UserName: board.UserName, Field: handicap.String()})
+	return nil
+}
+
+func (b *Bingo) setFreeCells(board *BingoBoard, freeCells int) {
+	free := make([]int, 0)
+	open := make([]int, 0)
+	for i, word := range board.Content {
+		if word == FreeField {
+			free = append(free, i)
+		} else if !b.Completed[word] {
+			open = append(open, i)
+		}
+	}
+
+	// Remove surplus free cells by giving them new fields
+	for len(free) > freeCells {
+		unused := b.unusedWords(board)
+		if len(unused) == 0 {
+			break
+		}
+		board.Content[free[0]] = unused[rand.Intn(len(unused))]
+		free = free[1:]
+	}
+
+	// Free the hardest open fields
+	rand.Shuffle(len(open), func(i, j int) { open[i], open[j] = open[j], open[i] })
+	sort.SliceStable(open, func(i, j int) bool {
+		return b.FieldDifficulty(board.Content[open[i]]) > b.FieldDifficulty(board.Content[open[j]])
+	})
+	for i := 0; len(free) < freeCells && i < len(open); i++ {
+		board.Content[open[i]] = FreeField
+		free = append(free, open[i])
+	}
+}
+
+// unusedWords returns all open fields of the bingo that are not on the board.
+func (b *Bingo) unusedWords(board *BingoBoard) []string {
+	unused := make([]string, 0)
+	for _, word := range b.Words {
+		if !b.Completed[word] && !contains(board.Content, word) {
+			unused = append(unused, word)
+		}
+	}
+	return unused
+}
@@ -0,0 +1,111 @@
+package bingo
+
+import (
+	"strconv"
+	"testing"
+)
+
+// newTestBingo returns a bingo with the fields f0 to f<words-1> and a board of
+// the given size for the player "player". The difficulty of a field is its number modulo 5 plus 1.
+func newTestBingo(words, size int) (*Bingo, *BingoBoard) {
+	bin := &Bingo{
+		Size:       size,
+		Boards:     make(map[string]*BingoBoard),
+		Completed:  make(map[string]bool),
+		Difficulty: make(map[string]int),
+	}
+	for i := 0; i < words; i++ {
+		word := "f" + strconv.Itoa(i)
+		bin.Words = append(bin.Words, word)
+		bin.Completed[word] = false
+		bin.Difficulty[word] = i%5 + 1
+	}
+	bin.Wordsize = words
+	return bin, bin.CreateBoard("player", "player")
+}
+
+func countFree(board *BingoBoard) int {
+	free := 0
+	for _, word := range board.Content {
+		if word == FreeField {
+			free++
+		}
+	}
+	return free
+}
+
+func TestHandicapRerolls(t *testing.T) {
+	bin, board := newTestBingo(30, 9)
+	bin.TotalRerolls = 2
+	board.Rerolls = 2
+
+	steps := []struct {
+		extra    int
+		expected int
+	}{{3, 5}, {1, 3}, {0, 2}}
+	for _, step := range steps {
+		if err := bin.SetHandicap(board, Handicap{ExtraRerolls: step.extra}); err != nil {
+			t.Fatal(err)
+		}
+		if board.Rerolls != step.expected {
+			t.Errorf("expected %d rerolls with %d extra, got %d", step.expected, step.extra, board.Rerolls)
+		}
+	}
+
+	if err := bin.SetHandicap(board, Handicap{ExtraRerolls: -1}); err == nil {
+		t.Errorf("expected an error for negative rerolls")
+	}
+	if err := bin.SetHandicap(board, Handicap{FreeCells: 9}); err == nil {
+		t.Errorf("expected an error for a board without open cells")
+	}
+}
+
+func TestHandicapFreeCells(t *testing.T) {
+	bin, board := newTestBingo(30, 9)
+	completed := board.Content[0]
+	bin.Completed[completed] = true
+	original := append([]string(nil), board.Content...)
+
+	if err := bin.SetHandicap(board, Handicap{FreeCells: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if countFree(board) != 3 {
+		t.Fatalf("expected 3 free cells, got %v", board.Content)
+	}
+	if board.Content[0] != completed {
+		t.Errorf("expected completed fields to stay on the board")
+	}
+	easiestFreed, hardestOpen := MaxDifficulty, 0
+	for i, word := range board.Content {
+		switch {
+		case word == FreeField && bin.FieldDifficulty(original[i]) < easiestFreed:
+			easiestFreed = bin.FieldDifficulty(original[i])
+		case word != FreeField && word != completed && bin.FieldDifficulty(word) > hardestOpen:
+			hardestOpen = bin.FieldDifficulty(word)
+		}
+	}
+	if hardestOpen > easiestFreed {
+		t.Errorf("expected the hardest open fields to be freed, got %v from %v", board.Content, original)
+	}
+	for i, word := range board.Content {
+		if word == FreeField && !bin.IsDone(word) {
+			t.Errorf("expected free cell %d to count as done", i)
+		}
+	}
+
+	content := append([]string(nil), board.Content...)
+	if err := bin.SetHandicap(board, Handicap{FreeCells: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if countFree(board) != 1 {
+		t.Errorf("expected the surplus free cells to get fields, got %v", board.Content)
+	}
+	for i, word := range board.Content {
+		if content[i] != FreeField && word != content[i] {
+			t.Errorf("expected cell %d to keep %s, got %s", i, content[i], word)
+		}
+	}
+	if board.Handicap.String() != "1 free" {
+		t.Errorf("unexpected description %q", board.Handicap.String())
+	}
+}
@@ -0,0 +1,87 @@
+package bingo
+
+import (
+	"errors"
+)
+
+// ReplaceWord replaces a field in the word list and on every board.
+// The completion state of the old field is not carried over.
+func (b *Bingo) ReplaceWord(oldWord, newWord string) error {
+	if newWord == "" || newWord == FreeField {
+		return errors.New("invalid field")
+	}
+	index := findIndex(b.Words, oldWord)
+	if index < 0 {
+		return ErrInvalidField
+	}
+	if contains(b.Words, newWord) {
+		return errors.New("field " + newWord + " already exists")
+	}
+
+	b.Words[index] = newWord
+	delete(b.Completed, oldWord)
+	delete(b.CompletedAt, oldWord)
+	b.Completed[newWord] = false
+	if difficulty, exists := b.Difficulty[oldWord]; exists {
+		delete(b.Difficulty, oldWord)
+		b.Difficulty[newWord] = difficulty
+	}
+
+	for _, board := range b.Boards {
+		i := findIndex(board.Content, oldWord)
+		if i >= 0 {
+			board.Content[i] = newWord
+		}
+	}
+
+	b.Record(Event{Type: EventWordReplaced, Field: oldWord, NewField: newWord})
+	return nil
+}
+
+// RegenerateBoard gives the board completely new fields, keeping its handicap.
+func (b *Bingo) RegenerateBoard(board *BingoBoard) {
+	target := board.Handicap.Difficulty
+	if target == 0 {
+		target = b.TargetDifficulty
+	}
+	board.Content = b.generateContent(target)
+	b.setFreeCells(board, board.Handicap.FreeCells)
+
+	b.Record(Event{Type: EventBoardRegenerated, BoardId: board.Id, UserName: board.UserName})
+}
+
+// RemoveBoard removes a player and its placement from the bingo.
+func (b *Bingo) RemoveBoard(boardId string) error {
+	board, exists := b.Boards[boardId]
+	if !exists {
+		return errors.New("player not found")
+	}
+	delete(b.Boards, boardId)
+
+	winners := make([]Winner, 0, len(b.Winners))
+	for _, winner := range b.Winners {
+		if winner.BoardId != boardId {
+			winners = append(winners, winner)
+		}
+	}
+	b.Winners = winners
+
+	b.Record(Event{Type: EventPlayerRemoved, BoardId: board.Id, UserName: board.UserName})
+	return nil
+}
+
+// AddWords adds new fields to the word list. Fields that already exist are skipped.
+func (b *Bingo) AddWords(words []string) []string {
+	added := make([]string, 0, len(words))
+	for _, word := range words {
+		if word == "" || word == FreeField || contains(b.Words, word) {
+			continue
+		}
+		b.Words = append(b.Words, word)
+		b.Completed[word] = false
+		added = append(added, word)
+		b.Record(Event{Type: EventWordsAdded, Field: word})
+	}
+	b.Wordsize = len(b.Words)
+	return added
+}
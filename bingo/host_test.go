@@ -0,0 +1,84 @@
+package bingo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReplaceWord(t *testing.T) {
+	bin, board := newTestBingo(30, 9)
+	old := board.Content[2]
+	difficulty := bin.FieldDifficulty(old)
+	bin.Completed[old] = true
+	bin.CompletedAt = map[string]time.Time{old: time.Now()}
+
+	if err := bin.ReplaceWord(old, "new"); err != nil {
+		t.Fatal(err)
+	}
+	if board.Content[2] != "new" || contains(bin.Words, old) || !contains(bin.Words, "new") {
+		t.Errorf("expected the field to be replaced on the board and in the word list")
+	}
+	if _, exists := bin.Completed[old]; exists || bin.Completed["new"] {
+		t.Errorf("expected the completion not to be carried over, got %v", bin.Completed)
+	}
+	if _, exists := bin.CompletedAt[old]; exists {
+		t.Errorf("expected the completion time of the old field to be removed")
+	}
+	if bin.FieldDifficulty("new") != difficulty {
+		t.Errorf("expected the difficulty to be carried over, got %d", bin.FieldDifficulty("new"))
+	}
+
+	first, second := bin.Words[0], bin.Words[1]
+	for _, test := range []struct{ old, new string }{{"unknown", "other"}, {first, ""}, {first, FreeField}, {first, second}} {
+		if err := bin.ReplaceWord(test.old, test.new); err == nil {
+			t.Errorf("expected an error replacing %q with %q", test.old, test.new)
+		}
+	}
+}
+
+func TestRemoveBoard(t *testing.T) {
+	bin, board := newTestBingo(30, 9)
+	other := bin.CreateBoard("other", "other")
+	start := time.Now()
+	bin.Winners = []Winner{{BoardId: board.Id, Time: start}, {BoardId: other.Id, Time: start.Add(time.Minute)}}
+
+	if err := bin.RemoveBoard(board.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := bin.Boards[board.Id]; exists {
+		t.Errorf("expected the board to be removed")
+	}
+	if bin.Placement(other.Id) != 1 {
+		t.Errorf("expected the other board to move up, got placement %d", bin.Placement(other.Id))
+	}
+	if err := bin.RemoveBoard(board.Id); err == nil {
+		t.Errorf("expected an error removing a board twice")
+	}
+}
+
+func TestAddWords(t *testing.T) {
+	bin, _ := newTestBingo(10, 9)
+
+	added := bin.AddWords([]string{"new", "f1", "", FreeField, "new", "other"})
+	if len(added) != 2 || added[0] != "new" || added[1] != "other" {
+		t.Errorf("expected only the new fields to be added, got %v", added)
+	}
+	if bin.Wordsize != 12 || len(bin.Words) != 12 {
+		t.Errorf("expected 12 fields, got %d", bin.Wordsize)
+	}
+	if completed, exists := bin.Completed["other"]; !exists || completed {
+		t.Errorf("expected the new fields to be open")
+	}
+}
+
+func TestRegenerateBoard(t *testing.T) {
+	bin, board := newTestBingo(30, 9)
+	if err := bin.SetHandicap(board, Handicap{FreeCells: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	bin.RegenerateBoard(board)
+	if len(board.Content) != 9 || countFree(board) != 2 {
+		t.Errorf("expected a new board that keeps its free cells, got %v", board.Content)
+	}
+}
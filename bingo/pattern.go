@@ -0,0 +1,64 @@
+package bingo
+
+import "sort"
+
+// DefaultWinPattern is used for bingos without a win pattern.
+const DefaultWinPattern = "line"
+
+// WinPatterns return the shapes of a board with the given width that count as a bingo.
+var WinPatterns = map[string]func(width int) [][]int{
+	"line": lines,
+	"row": func(width int) [][]int {
+		rows := make([][]int, 0, width)
+		for i := 0; i < width; i++ {
+			row := make([]int, 0, width)
+			for j := 0; j < width; j++ {
+				row = append(row, i*width+j)
+			}
+			rows = append(rows, row)
+		}
+		return rows
+	},
+	"corners": func(width int) [][]int {
+		if width < 2 {
+			return nil
+		}
+		return [][]int{{0, width - 1, width * (width - 1), width*width - 1}}
+	},
+	"x": func(width int) [][]int {
+		cells := make([]int, 0, 2*width)
+		for i := 0; i < width; i++ {
+			cells = append(cells, i*width+i)
+			if width-1-i != i {
+				cells = append(cells, i*width+width-1-i)
+			}
+		}
+		return [][]int{cells}
+	},
+	"blackout": func(width int) [][]int {
+		cells := make([]int, 0, width*width)
+		for i := 0; i < width*width; i++ {
+			cells = append(cells, i)
+		}
+		return [][]int{cells}
+	},
+}
+
+// WinPatternNames returns the names of all win patterns in alphabetical order.
+func WinPatternNames() []string {
+	names := make([]string, 0, len(WinPatterns))
+	for name := range WinPatterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// winLines returns the shapes that complete a bingo on a board with the given width.
+func (b *Bingo) winLines(width int) [][]int {
+	pattern, exists := WinPatterns[b.WinPattern]
+	if !exists {
+		pattern = WinPatterns[DefaultWinPattern]
+	}
+	return pattern(width)
+}
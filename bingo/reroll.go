@@ -0,0 +1,290 @@
+package bingo
+
+import (
+	"Bingo/config"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RerollPolicy controls how players of a bingo may change their boards.
+type RerollPolicy struct {
+	// Cost is the number of rerolls a single reroll consumes. 0 counts as 1.
+	Cost int `json:"cost"`
+	// SwapCost is the number of rerolls swapping two cells consumes. 0 disables swapping.
+	SwapCost int `json:"swapCost"`
+	// CooldownSeconds is the minimum time between two rerolls or swaps of a board.
+	CooldownSeconds int `json:"cooldownSeconds"`
+	// RegenerateSeconds regains one reroll every given seconds. 0 disables regeneration.
+	RegenerateSeconds int `json:"regenerateSeconds"`
+	// MaxRerolls caps regenerated and earned rerolls. 0 means no cap.
+	MaxRerolls int `json:"maxRerolls"`
+	// LineBonus is the number of rerolls earned for every completed line.
+	LineBonus int `json:"lineBonus"`
+	// LockWhenRunning forbids rerolls once the first field was completed.
+	LockWhenRunning bool `json:"lockWhenRunning"`
+	// ExcludeRecent keeps the given number of fields a board rerolled away last from coming back.
+	ExcludeRecent int `json:"excludeRecent"`
+}
+
+// RerollState is the reroll information shown on a board.
+type RerollState struct {
+	Rerolls       int           `json:"rerolls"`
+	Locked        bool          `json:"locked"`
+	Cooldown      time.Duration `json:"cooldown"`
+	NextRegen     time.Duration `json:"nextRegen"`
+	Cost          int           `json:"cost"`
+	SwapCost      int           `json:"swapCost"`
+	SwapAvailable bool          `json:"swapAvailable"`
+}
+
+// RerollErrorKind classifies why a reroll or swap was rejected.
+type RerollErrorKind int
+
+const (
+	// RerollInvalid means the request referenced a cell that does not exist.
+	RerollInvalid RerollErrorKind = iota
+	// RerollForbidden means the rules do not allow the action right now.
+	RerollForbidden
+	// RerollConflict means the board changed or the cell can not be rerolled anymore.
+	RerollConflict
+	// RerollTooEarly means the cooldown has not passed yet.
+	RerollTooEarly
+)
+
+// RerollError is returned when a reroll or swap is rejected.
+type RerollError struct {
+	Kind    RerollErrorKind
+	Message string
+}
+
+func (e *RerollError) Error() string {
+	return e.Message
+}
+
+var (
+	ErrRerollsLocked = &RerollError{RerollForbidden, "rerolls are locked while the game is running"}
+	ErrNoRerolls     = &RerollError{RerollForbidden, "not enough rerolls left"}
+	ErrSwapDisabled  = &RerollError{RerollForbidden, "swapping is disabled"}
+	ErrCooldown      = &RerollError{RerollTooEarly, "rerolls are on cooldown"}
+	ErrInvalidField  = &RerollError{RerollInvalid, "field is not on the board"}
+	ErrStaleField    = &RerollError{RerollConflict, "the board has changed, reload the page"}
+	ErrFieldDone     = &RerollError{RerollConflict, "field is already completed"}
+	ErrNoCandidates  = &RerollError{RerollConflict, "no fields left to reroll into"}
+
+	// now returns the current time of the reroll rules, tests replace it.
+	now = time.Now
+)
+
+// PolicyByName returns the reroll policy with the given name from the game settings.
+func PolicyByName(settings config.GameSettings, name string) (RerollPolicy, error) {
+	policy, exists := settings.RerollPolicies[name]
+	if !exists {
+		return RerollPolicy{}, errors.New("unknown reroll policy " + name)
+	}
+	return RerollPolicy(policy), nil
+}
+
+func (p RerollPolicy) cost() int {
+	if p.Cost <= 0 {
+		return 1
+	}
+	return p.Cost
+}
+
+// Running reports whether any field of the bingo has been completed.
+func (b *Bingo) Running() bool {
+	for _, done := range b.Completed {
+		if done {
+			return true
+		}
+	}
+	return false
+}
+
+// RefreshRerolls grants the rerolls the board earned by regeneration and completed lines since the last refresh.
+func (b *Bingo) RefreshRerolls(board *BingoBoard) {
+	policy := b.RerollPolicy
+	current := now()
+
+	lines := b.CountLines(board)
+	if lines > board.LinesRewarded {
+		b.grantRerolls(board, (lines-board.LinesRewarded)*policy.LineBonus)
+		board.LinesRewarded = lines
+	}
+
+	if policy.RegenerateSeconds <= 0 {
+		return
+	}
+	period := time.Duration(policy.RegenerateSeconds) * time.Second
+	if board.RegeneratedAt.IsZero() || (policy.MaxRerolls > 0 && board.Rerolls >= policy.MaxRerolls) {
+		board.RegeneratedAt = current
+		return
+	}
+
+	ticks := int(current.Sub(board.RegeneratedAt) / period)
+	if ticks > 0 {
+		b.grantRerolls(board, ticks)
+		board.RegeneratedAt = board.RegeneratedAt.Add(time.Duration(ticks) * period)
+	}
+}
+
+func (b *Bingo) grantRerolls(board *BingoBoard, rerolls int) {
+	if rerolls <= 0 {
+		return
+	}
+	max := b.RerollPolicy.MaxRerolls
+	if max > 0 && board.Rerolls+rerolls > max {
+		rerolls = max - board.Rerolls
+	}
+	if rerolls > 0 {
+		board.Rerolls += rerolls
+	}
+}
+
+// RerollState returns the current reroll state of the board.
+func (b *Bingo) RerollState(board *BingoBoard) RerollState {
+	b.RefreshRerolls(board)
+	policy := b.RerollPolicy
+
+	state := RerollState{
+		Rerolls:       board.Rerolls,
+		Locked:        policy.LockWhenRunning && b.Running(),
+		Cost:          policy.cost(),
+		SwapCost:      policy.SwapCost,
+		SwapAvailable: policy.SwapCost > 0,
+	}
+
+	if !board.LastReroll.IsZero() {
+		cooldown := board.LastReroll.Add(time.Duration(policy.CooldownSeconds) * time.Second).Sub(now())
+		if cooldown > 0 {
+			state.Cooldown = cooldown
+		}
+	}
+	if policy.RegenerateSeconds > 0 && (policy.MaxRerolls == 0 || board.Rerolls < policy.MaxRerolls) {
+		state.NextRegen = board.RegeneratedAt.Add(time.Duration(policy.RegenerateSeconds) * time.Second).Sub(now())
+	}
+	return state
+}
+
+// checkReroll returns why the board can not spend cost rerolls right now.
+func (b *Bingo) checkReroll(board *BingoBoard, cost int) error {
+	state := b.RerollState(board)
+	if state.Locked {
+		return ErrRerollsLocked
+	}
+	if state.Cooldown > 0 {
+		return ErrCooldown
+	}
+	if board.Rerolls < cost {
+		return ErrNoRerolls
+	}
+	return nil
+}
+
+func (b *Bingo) spendRerolls(board *BingoBoard, cost int) {
+	if b.RerollPolicy.MaxRerolls > 0 && board.Rerolls >= b.RerollPolicy.MaxRerolls {
+		board.RegeneratedAt = now()
+	}
+	board.Rerolls -= cost
+	board.RerollsUsed += cost
+	board.LastReroll = now()
+}
+
+// Reroll replaces the open field at index with a random open field that is
+// not on the board and was not rerolled away recently. expected is the field
+// the player saw at the index, an empty value skips that check.
+func (b *Bingo) Reroll(board *BingoBoard, index int, expected string) (string, error) {
+	oldWord, err := b.openCell(board, index, expected)
+	if err != nil {
+		return "", err
+	}
+
+	cost := b.RerollPolicy.cost()
+	err = b.checkReroll(board, cost)
+	if err != nil {
+		return "", err
+	}
+
+	possibleWords := b.RerollCandidates(board)
+	if len(possibleWords) == 0 {
+		return "", ErrNoCandidates
+	}
+
+	newWord := possibleWords[rand.Intn(len(possibleWords))]
+	board.Content[index] = newWord
+	b.RecordReroll(oldWord)
+	b.spendRerolls(board, cost)
+
+	b.Record(Event{Type: EventRerolled, BoardId: board.Id, UserName: board.UserName, Field: oldWord, NewField: newWord})
+
+	board.RecentRerolls = append(board.RecentRerolls, oldWord)
+	if len(board.RecentRerolls) > b.RerollPolicy.ExcludeRecent {
+		board.RecentRerolls = board.RecentRerolls[len(board.RecentRerolls)-b.RerollPolicy.ExcludeRecent:]
+	}
+
+	return newWord, nil
+}
+
+// RerollCandidates returns the fields a cell of the board can be rerolled into.
+func (b *Bingo) RerollCandidates(board *BingoBoard) []string {
+	candidates := make([]string, 0)
+	for _, word := range b.unusedWords(board) {
+		if !contains(board.RecentRerolls, word) {
+			candidates = append(candidates, word)
+		}
+	}
+	return candidates
+}
+
+// Swap exchanges the positions of two open cells on the board.
+func (b *Bingo) Swap(board *BingoBoard, first, second int) error {
+	if b.RerollPolicy.SwapCost <= 0 {
+		return ErrSwapDisabled
+	}
+	if first == second {
+		return ErrInvalidField
+	}
+	_, err := b.openCell(board, first, "")
+	if err != nil {
+		return err
+	}
+	_, err = b.openCell(board, second, "")
+	if err != nil {
+		return err
+	}
+
+	err = b.checkReroll(board, b.RerollPolicy.SwapCost)
+	if err != nil {
+		return err
+	}
+
+	board.Content[first], board.Content[second] = board.Content[second], board.Content[first]
+	b.spendRerolls(board, b.RerollPolicy.SwapCost)
+	b.Record(Event{Type: EventSwapped, BoardId: board.Id, UserName: board.UserName, Field: board.Content[second], NewField: board.Content[first]})
+	return nil
+}
+
+// openCell validates that index points to an open cell holding the expected field.
+func (b *Bingo) openCell(board *BingoBoard, index int, expected string) (string, error) {
+	if index < 0 || index >= len(board.Content) {
+		return "", ErrInvalidField
+	}
+	word := board.Content[index]
+	if expected != "" && word != expected {
+		return "", ErrStaleField
+	}
+	if b.IsDone(word) {
+		return "", ErrFieldDone
+	}
+	return word, nil
+}
+
+func findIndex(array []string, val string) int {
+	for i, s := range array {
+		if val == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,218 @@
+package bingo
+
+import (
+	"testing"
+	"time"
+)
+
+// setNow fixes the time of the reroll rules for the test.
+func setNow(t *testing.T, current time.Time) {
+	now = func() time.Time { return current }
+	t.Cleanup(func() { now = time.Now })
+}
+
+func TestRefreshRerolls(t *testing.T) {
+	start := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		policy      RerollPolicy
+		rerolls     int
+		elapsed     time.Duration
+		expected    int
+		regenerated time.Duration
+	}{
+		{"no regeneration", RerollPolicy{}, 1, time.Hour, 1, 0},
+		{"before the first tick", RerollPolicy{RegenerateSeconds: 60}, 1, 59 * time.Second, 1, 0},
+		{"ticks", RerollPolicy{RegenerateSeconds: 60}, 1, 150 * time.Second, 3, 120 * time.Second},
+		{"capped", RerollPolicy{RegenerateSeconds: 60, MaxRerolls: 3}, 1, time.Hour, 3, time.Hour},
+		{"at the cap", RerollPolicy{RegenerateSeconds: 60, MaxRerolls: 3}, 3, time.Hour, 3, time.Hour},
+	}
+	for _, test := range tests {
+		bin, board := newTestBingo(30, 9)
+		bin.RerollPolicy = test.policy
+		board.Rerolls = test.rerolls
+		board.RegeneratedAt = start
+		setNow(t, start.Add(test.elapsed))
+
+		bin.RefreshRerolls(board)
+		if board.Rerolls != test.expected {
+			t.Errorf("%s: expected %d rerolls, got %d", test.name, test.expected, board.Rerolls)
+		}
+		if test.policy.RegenerateSeconds > 0 && !board.RegeneratedAt.Equal(start.Add(test.regenerated)) {
+			t.Errorf("%s: expected the regeneration after %s, got %s", test.name, test.regenerated, board.RegeneratedAt.Sub(start))
+		}
+	}
+}
+
+func TestLineBonus(t *testing.T) {
+	bin, board := newTestBingo(30, 9)
+	bin.RerollPolicy = RerollPolicy{LineBonus: 2, MaxRerolls: 5}
+	board.Rerolls = 0
+	for _, word := range board.Content[:3] {
+		bin.Completed[word] = true
+	}
+
+	bin.RefreshRerolls(board)
+	bin.RefreshRerolls(board)
+	if board.Rerolls != 2 || board.LinesRewarded != 1 {
+		t.Errorf("expected the bonus once for one line, got %d rerolls for %d lines", board.Rerolls, board.LinesRewarded)
+	}
+
+	for _, word := range board.Content[3:9] {
+		bin.Completed[word] = true
+	}
+	bin.RefreshRerolls(board)
+	if board.Rerolls != 5 {
+		t.Errorf("expected the bonus to be capped, got %d rerolls", board.Rerolls)
+	}
+}
+
+func TestRerollRules(t *testing.T) {
+	start := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		policy   RerollPolicy
+		rerolls  int
+		last     time.Duration
+		running  bool
+		expected error
+	}{
+		{"allowed", RerollPolicy{}, 1, 0, false, nil},
+		{"no rerolls", RerollPolicy{}, 0, 0, false, ErrNoRerolls},
+		{"cost", RerollPolicy{Cost: 2}, 1, 0, false, ErrNoRerolls},
+		{"cooldown", RerollPolicy{CooldownSeconds: 30}, 1, 10 * time.Second, false, ErrCooldown},
+		{"cooldown passed", RerollPolicy{CooldownSeconds: 30}, 1, 30 * time.Second, false, nil},
+		{"locked", RerollPolicy{LockWhenRunning: true}, 1, 0, true, ErrRerollsLocked},
+		{"not yet locked", RerollPolicy{LockWhenRunning: true}, 1, 0, false, nil},
+		{"running", RerollPolicy{}, 1, 0, true, nil},
+	}
+	for _, test := range tests {
+		bin, board := newTestBingo(30, 9)
+		bin.RerollPolicy = test.policy
+		board.Rerolls = test.rerolls
+		if test.last > 0 {
+			board.LastReroll = start
+		}
+		if test.running {
+			bin.Completed[board.Content[8]] = true
+		}
+		setNow(t, start.Add(test.last))
+
+		_, err := bin.Reroll(board, 0, board.Content[0])
+		if err != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, err)
+		}
+		if err == ErrCooldown && err.(*RerollError).Kind != RerollTooEarly {
+			t.Errorf("%s: expected the cooldown to be too early", test.name)
+		}
+	}
+}
+
+func TestRerollSpends(t *testing.T) {
+	start := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	setNow(t, start)
+	bin, board := newTestBingo(30, 9)
+	bin.RerollPolicy = RerollPolicy{Cost: 2}
+	board.Rerolls = 3
+	old := board.Content[4]
+
+	newWord, err := bin.Reroll(board, 4, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newWord == old || board.Content[4] != newWord || contains(board.Content, old) {
+		t.Errorf("expected %s to be replaced, got %v", old, board.Content)
+	}
+	if board.Rerolls != 1 || board.RerollsUsed != 2 || !board.LastReroll.Equal(start) || bin.Rerolled[old] != 1 {
+		t.Errorf("expected the reroll to be paid, got %+v", board)
+	}
+}
+
+func TestSwapCost(t *testing.T) {
+	bin, board := newTestBingo(30, 9)
+	board.Rerolls = 3
+	if err := bin.Swap(board, 0, 1); err != ErrSwapDisabled {
+		t.Errorf("expected swapping to be disabled, got %v", err)
+	}
+
+	bin.RerollPolicy = RerollPolicy{SwapCost: 2}
+	first, second := board.Content[0], board.Content[1]
+	if err := bin.Swap(board, 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if board.Content[0] != second || board.Content[1] != first || board.Rerolls != 1 {
+		t.Errorf("expected the cells to be swapped for 2 rerolls, got %v with %d rerolls", board.Content, board.Rerolls)
+	}
+	if err := bin.Swap(board, 0, 1); err != ErrNoRerolls {
+		t.Errorf("expected not enough rerolls, got %v", err)
+	}
+}
+
+func TestExcludeRecent(t *testing.T) {
+	bin, board := newTestBingo(4, 1)
+	bin.RerollPolicy = RerollPolicy{ExcludeRecent: 2}
+	board.Rerolls = 10
+
+	for i := 0; i < 6; i++ {
+		recent := append([]string{board.Content[0]}, board.RecentRerolls...)
+		newWord, err := bin.Reroll(board, 0, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if contains(recent, newWord) {
+			t.Fatalf("expected %s not to come back, recently rerolled %v", newWord, recent)
+		}
+		if len(board.RecentRerolls) > 2 {
+			t.Fatalf("expected the last 2 rerolled fields, got %v", board.RecentRerolls)
+		}
+	}
+}
+
+func TestRerollInvalidCells(t *testing.T) {
+	bin, board := newTestBingo(30, 9)
+	bin.RerollPolicy = RerollPolicy{SwapCost: 1}
+	board.Rerolls = 5
+	bin.Completed[board.Content[1]] = true
+	content := append([]string(nil), board.Content...)
+
+	tests := []struct {
+		name     string
+		index    int
+		expected string
+		err      error
+	}{
+		{"negative index", -1, "", ErrInvalidField},
+		{"index after the board", 9, "", ErrInvalidField},
+		{"far out of range", 1000, board.Content[0], ErrInvalidField},
+		{"stale field", 0, board.Content[2], ErrStaleField},
+		{"field not on the board", 0, "unknown", ErrStaleField},
+		{"completed field", 1, board.Content[1], ErrFieldDone},
+		{"completed field without expected", 1, "", ErrFieldDone},
+	}
+	for _, test := range tests {
+		if _, err := bin.Reroll(board, test.index, test.expected); err != test.err {
+			t.Errorf("reroll %s: expected %v, got %v", test.name, test.err, err)
+		}
+		if test.expected != "" {
+			continue
+		}
+		if err := bin.Swap(board, test.index, 0); err != test.err {
+			t.Errorf("swap %s: expected %v, got %v", test.name, test.err, err)
+		}
+		if err := bin.Swap(board, 0, test.index); err != test.err {
+			t.Errorf("swap %s: expected %v, got %v", test.name, test.err, err)
+		}
+	}
+	if err := bin.Swap(board, 3, 3); err != ErrInvalidField {
+		t.Errorf("expected an error swapping a cell with itself, got %v", err)
+	}
+
+	for i, word := range board.Content {
+		if word != content[i] {
+			t.Fatalf("expected the board to be unchanged, got %v", board.Content)
+		}
+	}
+	if board.Rerolls != 5 || board.RerollsUsed != 0 {
+		t.Errorf("expected no rerolls to be spent, got %d", board.Rerolls)
+	}
+}
@@ -0,0 +1,72 @@
+package bingo
+
+import (
+	"Bingo/random"
+	"errors"
+	"net/url"
+)
+
+// WebhookEvents are the event types a webhook can receive.
+var WebhookEvents = []string{
+	EventFieldCompleted,
+	EventFieldReset,
+	EventPlayerJoined,
+	EventRerolled,
+	EventSwapped,
+	EventBingo,
+	EventGameEnded,
+}
+
+// Webhook is an url that receives events of bingos as signed POST requests.
+type Webhook struct {
+	Id  string `json:"id"`
+	Url string `json:"url"`
+	// Secret is the key of the HMAC-SHA256 signature of every request.
+	Secret string `json:"secret"`
+	// Events are the event types the webhook receives, all of WebhookEvents if empty.
+	Events []string `json:"events,omitempty"`
+}
+
+// NewWebhook creates a webhook with a new id and secret.
+func NewWebhook(address string, events []string) (Webhook, error) {
+	parsed, err := url.Parse(address)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return Webhook{}, errors.New("the webhook url must be an absolute http or https url")
+	}
+	for _, event := range events {
+		if !contains(WebhookEvents, event) {
+			return Webhook{}, errors.New("unknown event " + event)
+		}
+	}
+
+	// The secret signs the requests, so unlike the ids it must not be predictable
+	secret, err := newSecret()
+	if err != nil {
+		return Webhook{}, err
+	}
+	return Webhook{
+		Id:     random.RandSeq(8),
+		Url:    address,
+		Secret: secret,
+		Events: events,
+	}, nil
+}
+
+// Wants reports whether the webhook receives events of the type.
+func (w Webhook) Wants(eventType string) bool {
+	if len(w.Events) == 0 {
+		return contains(WebhookEvents, eventType)
+	}
+	return contains(w.Events, eventType)
+}
+
+// RemoveWebhook returns the webhooks without the one with the id and whether it was found.
+func RemoveWebhook(webhooks []Webhook, id string) ([]Webhook, bool) {
+	remaining := make([]Webhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		if webhook.Id != id {
+			remaining = append(remaining, webhook)
+		}
+	}
+	return remaining, len(remaining) != len(webhooks)
+}
@@ -0,0 +1,43 @@
+package bot
+
+import (
+	"Bingo/bingo"
+
+	"github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+var automationKeyCommand = &discordgo.ApplicationCommand{
+	Name:         "automation-key",
+	Description:  "Shows you the key scripts mark fields of your running bingo with",
+	DMPermission: &noDMs,
+}
+
+func init() {
+	Commands = append(Commands, automationKeyCommand)
+	CommandHandlers["automation-key"] = handleAutomationKey
+}
+
+// handleAutomationKey shows the automation key to the host only, as the bingo id is public.
+func handleAutomationKey(p Platform, i *discordgo.InteractionCreate) {
+	bin := bingo.Latest(i.GuildID)
+	if bin == nil || bin.OwnerId != i.Member.User.ID {
+		respondEphemeral(p, i, "You are not hosting a running bingo in this server.")
+		return
+	}
+
+	bin.Lock()
+	created, err := bin.EnsureApiKey()
+	if created {
+		bin.Store(settings().StoragePath)
+	}
+	key := bin.ApiKey
+	bin.Unlock()
+	if err != nil {
+		log.WithError(err).Error("Failed to create the api key")
+		respondEphemeral(p, i, "Could not create the automation key.")
+		return
+	}
+	respondEphemeral(p, i, "Automation key of bingo "+bin.Id+": `"+key+"`\n"+
+		"Send it as `Authorization: Bearer <key>` to "+settings().BaseUrl+"/api/automation/"+bin.Id)
+}
@@ -0,0 +1,376 @@
+package bot
+
+import (
+	"Bingo/bingo"
+	"Bingo/render"
+	"hash/fnv"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// maxButtonBoardWidth is the widest board rendered as buttons. Discord allows
+	// five rows of five components and one row is needed for the reroll menu.
+	maxButtonBoardWidth = 4
+	maxLabelLength      = 80
+)
+
+// boardMessage is a message in discord that shows a board and is updated live.
+type boardMessage struct {
+	ChannelId string
+	MessageId string
+	BoardId   string
+}
+
+var (
+	boardCommand = &discordgo.ApplicationCommand{
+		Name:        "board",
+		Description: "Sends you your board of the running bingo as a direct message",
+	}
+
+	ComponentHandlers = map[string]func(p Platform, i *discordgo.InteractionCreate, args []string){
+		"reroll": handleRerollSelect,
+	}
+
+	boardMessages     = make(map[string][]boardMessage)
+	boardMessagesLock sync.Mutex
+	// pendingEdits holds the latest rendered board messages of each bingo until its worker edits them.
+	pendingEdits = make(map[string]*boardEdit)
+	// editing holds the bingos that have a running edit worker.
+	editing = make(map[string]bool)
+)
+
+// boardEdit are the rendered board messages of a bingo by message id.
+type boardEdit struct {
+	messages []boardMessage
+	sends    map[string]*discordgo.MessageSend
+}
+
+func init() {
+	Commands = append(Commands, boardCommand)
+	CommandHandlers["board"] = handleBoardCommand
+}
+
+func handleBoardCommand(p Platform, i *discordgo.InteractionCreate) {
+	bin := bingo.Latest(i.GuildID)
+	if bin == nil {
+		respondEphemeral(p, i, "There is no running bingo in this server.")
+		return
+	}
+	bin.Lock()
+	board, exists := bin.Boards[i.Member.User.ID]
+	bin.Unlock()
+	if !exists {
+		respondEphemeral(p, i, "You have not joined the bingo yet.")
+		return
+	}
+
+	err := SendBoard(bin, board)
+	if err != nil {
+		log.WithError(err).Error("Error sending board")
+		respondEphemeral(p, i, "Could not send you the board.")
+		return
+	}
+	respondEphemeral(p, i, "Your board was sent to you as a direct message.")
+}
+
+// SendBoard sends the board to its player as a direct message that is kept up to date.
+// The caller must not hold the lock of the bingo, it is only taken to render the board.
+func SendBoard(bin *bingo.Bingo, board *bingo.BingoBoard) error {
+	bin.Lock()
+	send := boardMessageSend(bin, board)
+	bin.Unlock()
+
+	msg, err := platform.SendDM(board.Id, send)
+	if err != nil {
+		return err
+	}
+
+	boardMessagesLock.Lock()
+	boardMessages[bin.Id] = append(boardMessages[bin.Id], boardMessage{
+		ChannelId: msg.ChannelID,
+		MessageId: msg.ID,
+		BoardId:   board.Id,
+	})
+	boardMessagesLock.Unlock()
+
+	// Fields changed while sending were not edited into the new message yet.
+	bin.Lock()
+	if !reflect.DeepEqual(boardMessageSend(bin, board), send) {
+		RefreshBoards(bin)
+	}
+	bin.Unlock()
+	return nil
+}
+
+// RefreshBoards updates all board messages of the bingo after its fields or boards changed.
+// The messages are rendered right away and edited in the background so the caller never waits for discord.
+// A single worker per bingo edits the messages, renders that were not edited yet are replaced by newer ones.
+func RefreshBoards(bin *bingo.Bingo) {
+	if platform == nil {
+		return
+	}
+
+	boardMessagesLock.Lock()
+	messages := append([]boardMessage(nil), boardMessages[bin.Id]...)
+	boardMessagesLock.Unlock()
+
+	sends := make(map[string]*discordgo.MessageSend, len(messages))
+	for _, message := range messages {
+		if board, exists := bin.Boards[message.BoardId]; exists {
+			sends[message.MessageId] = boardMessageSend(bin, board)
+		}
+	}
+
+	boardMessagesLock.Lock()
+	defer boardMessagesLock.Unlock()
+	pendingEdits[bin.Id] = &boardEdit{messages: messages, sends: sends}
+	if !editing[bin.Id] {
+		editing[bin.Id] = true
+		go editBoardMessages(bin.Id)
+	}
+}
+
+// editBoardMessages edits the board messages of the bingo until no newer render is pending.
+func editBoardMessages(bingoId string) {
+	for {
+		boardMessagesLock.Lock()
+		edit := pendingEdits[bingoId]
+		delete(pendingEdits, bingoId)
+		if edit == nil {
+			delete(editing, bingoId)
+			boardMessagesLock.Unlock()
+			return
+		}
+		boardMessagesLock.Unlock()
+
+		gone := make(map[string]bool)
+		for _, message := range edit.messages {
+			send, exists := edit.sends[message.MessageId]
+			if !exists {
+				gone[message.MessageId] = true
+				continue
+			}
+
+			err := platform.EditMessage(&discordgo.MessageEdit{
+				ID:         message.MessageId,
+				Channel:    message.ChannelId,
+				Content:    &send.Content,
+				Embeds:     send.Embeds,
+				Components: send.Components,
+			})
+			if err != nil {
+				log.WithError(err).Warn("Could not update board message")
+				gone[message.MessageId] = true
+			}
+		}
+		forgetBoardMessages(bingoId, gone)
+	}
+}
+
+// forgetBoardMessages stops updating the messages of the bingo, e.g. because they were deleted.
+func forgetBoardMessages(bingoId string, gone map[string]bool) {
+	if len(gone) == 0 {
+		return
+	}
+
+	boardMessagesLock.Lock()
+	defer boardMessagesLock.Unlock()
+	remaining := make([]boardMessage, 0, len(boardMessages[bingoId]))
+	for _, message := range boardMessages[bingoId] {
+		if !gone[message.MessageId] {
+			remaining = append(remaining, message)
+		}
+	}
+	boardMessages[bingoId] = remaining
+}
+
+// boardMessageSend renders the board as buttons for small boards or as an embed with an image for larger ones.
+func boardMessageSend(bin *bingo.Bingo, board *bingo.BingoBoard) *discordgo.MessageSend {
+	state := bin.RerollState(board)
+	content := "**" + board.UserName + "** · Rerolls: " + strconv.Itoa(state.Rerolls)
+	if state.Locked {
+		content += " (locked)"
+	}
+	if placement := bin.Placement(board.Id); placement > 0 {
+		content += " · BINGO #" + strconv.Itoa(placement)
+	}
+
+	send := &discordgo.MessageSend{
+		Content:    content,
+		Components: make([]discordgo.MessageComponent, 0),
+	}
+
+	width := board.Width()
+	if width <= maxButtonBoardWidth {
+		for row := 0; row < width; row++ {
+			buttons := make([]discordgo.MessageComponent, 0, width)
+			for column := 0; column < width; column++ {
+				index := row*width + column
+				field := board.Content[index]
+				style := discordgo.SecondaryButton
+				if bin.IsDone(field) {
+					style = discordgo.SuccessButton
+				}
+				buttons = append(buttons, discordgo.Button{
+					Label:    truncate(field, maxLabelLength),
+					Style:    style,
+					Disabled: true,
+					CustomID: "cell:" + bin.Id + ":" + board.Id + ":" + strconv.Itoa(index),
+				})
+			}
+			send.Components = append(send.Components, discordgo.ActionsRow{Components: buttons})
+		}
+	} else {
+		send.Embeds = []*discordgo.MessageEmbed{boardEmbed(bin, board, width)}
+	}
+
+	menu := rerollMenu(bin, board)
+	if menu != nil {
+		send.Components = append(send.Components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{*menu},
+		})
+	}
+	return send
+}
+
+func boardEmbed(bin *bingo.Bingo, board *bingo.BingoBoard, width int) *discordgo.MessageEmbed {
+	description := ""
+	for row := 0; row*width < len(board.Content); row++ {
+		cells := make([]string, 0, width)
+		for column := 0; column < width && row*width+column < len(board.Content); column++ {
+			field := board.Content[row*width+column]
+			if bin.IsDone(field) {
+				cells = append(cells, "✅ ~~"+field+"~~")
+			} else {
+				cells = append(cells, "⬜ "+field)
+			}
+		}
+		description += strings.Join(cells, " · ") + "\n\n"
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "Bingo " + bin.Id,
+		URL:         settings().BaseUrl + "/bingo/" + bin.Id + "/" + board.Id + "?pass=" + board.Password,
+		Description: description,
+		Image: &discordgo.MessageEmbedImage{
+			// The version makes discord fetch the image again after the board changed
+			URL: settings().BaseUrl + "/img/" + bin.Id + "/" + board.Id + ".png?pass=" + board.Password + "&v=" + strings.Trim(render.ETag(bin, board), `"`),
+		},
+	}
+}
+
+// rerollMenu returns a select menu with all cells that can be rerolled or nil if there are none.
+func rerollMenu(bin *bingo.Bingo, board *bingo.BingoBoard) *discordgo.SelectMenu {
+	state := bin.RerollState(board)
+	if state.Locked || state.Rerolls < state.Cost {
+		return nil
+	}
+
+	options := make([]discordgo.SelectMenuOption, 0, maxChoices)
+	for index, field := range board.Content {
+		if len(options) >= maxChoices {
+			break
+		}
+		if bin.IsDone(field) {
+			continue
+		}
+		options = append(options, discordgo.SelectMenuOption{
+			Label: truncate(field, maxLabelLength),
+			Value: strconv.Itoa(index) + ":" + fieldKey(field),
+		})
+	}
+	if len(options) == 0 {
+		return nil
+	}
+
+	return &discordgo.SelectMenu{
+		CustomID:    "reroll:" + bin.Id + ":" + board.Id,
+		Placeholder: "Reroll a cell",
+		Options:     options,
+	}
+}
+
+func handleRerollSelect(p Platform, i *discordgo.InteractionCreate, args []string) {
+	if len(args) < 2 {
+		return
+	}
+	bin, exists := bingo.Bingos[args[0]]
+	if !exists {
+		respondEphemeral(p, i, "This bingo is not running anymore.")
+		return
+	}
+	bin.Lock()
+	defer bin.Unlock()
+	board, exists := bin.Boards[args[1]]
+	if !exists || board.Id != interactionUser(i).ID {
+		respondEphemeral(p, i, "This is not your board.")
+		return
+	}
+
+	values := i.MessageComponentData().Values
+	if len(values) == 0 {
+		return
+	}
+	selected := strings.SplitN(values[0], ":", 2)
+	index, err := strconv.Atoi(selected[0])
+	if err != nil || len(selected) < 2 || index < 0 || index >= len(board.Content) {
+		respondEphemeral(p, i, "Invalid cell.")
+		return
+	}
+	if fieldKey(board.Content[index]) != selected[1] {
+		respondEphemeral(p, i, "Could not reroll: "+bingo.ErrStaleField.Error())
+		return
+	}
+
+	_, err = bin.Reroll(board, index, board.Content[index])
+	if err != nil {
+		respondEphemeral(p, i, "Could not reroll: "+err.Error())
+		return
+	}
+	Broadcast(bin.Id, []byte("Reroll"))
+	bin.Store(settings().StoragePath)
+
+	send := boardMessageSend(bin, board)
+	err = p.Respond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    send.Content,
+			Embeds:     send.Embeds,
+			Components: send.Components,
+		},
+	})
+	if err != nil {
+		log.WithError(err).Error("Error updating board message")
+	}
+	RefreshBoards(bin)
+}
+
+// interactionUser returns the user of an interaction in a guild or a direct message.
+func interactionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// fieldKey returns a short hash of the field for select menu values, as discord
+// limits them to 100 characters.
+func fieldKey(field string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(field))
+	return strconv.FormatUint(uint64(hash.Sum32()), 36)
+}
+
+func truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length-1]) + "…"
+}
@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"Bingo/bingo"
+	"Bingo/render"
+	"Bingo/sound"
+	"bytes"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+// SetField marks or unmarks a field of the bingo. It is the completion path
+// shared by the management plane and the slash commands: it broadcasts the new
+// state to the websocket clients, stores the bingo and queues the announcement
+// of a bingo. Nothing waits for discord so HTTP requests return immediately.
+// It returns false if the field does not exist or already had the value.
+// The caller must not hold the lock of the bingo.
+func SetField(bin *bingo.Bingo, word string, value bool) bool {
+	return SetFieldAt(bin, word, value, time.Now())
+}
+
+// SetFieldAt is SetField for a change that happened at the given time, e.g. in
+// a replayed match. The events, the completion time and new winners get that time.
+func SetFieldAt(bin *bingo.Bingo, word string, value bool, at time.Time) bool {
+	bin.Lock()
+	defer bin.Unlock()
+	current, exists := bin.Completed[word]
+	if !exists || current == value {
+		return false
+	}
+	setField(bin, word, at)
+	return true
+}
+
+// ToggleField flips a field of the bingo like SetField. Reading and flipping the value
+// under one lock makes two concurrent toggles unmark a field they both saw marked.
+// It returns false if the field does not exist.
+func ToggleField(bin *bingo.Bingo, word string) bool {
+	bin.Lock()
+	defer bin.Unlock()
+	if _, exists := bin.Completed[word]; !exists {
+		return false
+	}
+	setField(bin, word, time.Now())
+	return true
+}
+
+// setField flips an existing field and announces the change. The caller holds the lock of the bingo.
+func setField(bin *bingo.Bingo, word string, at time.Time) {
+	finished := bin.Finished()
+	lines := countAllLines(bin)
+	newValue, _ := bin.ToggleAt(word, at)
+
+	Broadcast(bin.Id, []byte(word+";"+strconv.FormatBool(newValue)))
+	winners := bin.UpdateWinnersAt(at)
+	bin.Store(settings().StoragePath)
+	RefreshBoards(bin)
+
+	event := ""
+	switch {
+	case !finished && bin.Finished():
+		event = sound.EventEnd
+	case len(winners) > 0 && bin.Placement(winners[0].Id) == 1:
+		event = sound.EventFirst
+	case countAllLines(bin) > lines:
+		event = sound.EventLine
+	}
+
+	var message *discordgo.MessageSend
+	if len(winners) > 0 {
+		var err error
+		message, err = winnersMessage(bin, winners)
+		if err != nil {
+			log.WithError(err).Error("Failed to render the winners")
+		}
+	}
+	enqueueAnnouncement(newAnnouncement(bin, message, event))
+}
+
+// countAllLines returns the number of completed lines on all boards of the bingo.
+func countAllLines(bin *bingo.Bingo) int {
+	lines := 0
+	for _, board := range bin.Boards {
+		lines += bin.CountLines(board)
+	}
+	return lines
+}
+
+// winnersMessage renders the boards of the new winners as images for the announcement.
+func winnersMessage(bin *bingo.Bingo, winners []*bingo.BingoBoard) (*discordgo.MessageSend, error) {
+	message := &discordgo.MessageSend{
+		Files: make([]*discordgo.File, 0, len(winners)),
+	}
+	for _, board := range winners {
+		message.Content += "🎉 **BINGO** for " + board.UserName + "!\n"
+
+		image := &bytes.Buffer{}
+		err := render.PNG(image, bin, board)
+		if err != nil {
+			return nil, err
+		}
+		message.Files = append(message.Files, &discordgo.File{
+			Name:        board.Id + ".png",
+			ContentType: "image/png",
+			Reader:      image,
+		})
+	}
+	return message, nil
+}
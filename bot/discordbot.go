@@ -1,22 +1,42 @@
 package bot
 
 import (
+	"Bingo/analytics"
 	"Bingo/bingo"
 	"Bingo/config"
-	"encoding/binary"
-	"encoding/json"
+	"Bingo/series"
+	"Bingo/stats"
 	"fmt"
-	"io"
 	"io/ioutil"
-	"os"
-	"os/signal"
-	"syscall"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	log "github.com/sirupsen/logrus"
 )
 
+// currentSettings is the config of the bot, replaced when the config is reloaded.
+var currentSettings atomic.Pointer[config.Config]
+
+func settings() *config.Config {
+	if cfg := currentSettings.Load(); cfg != nil {
+		return cfg
+	}
+	return config.Default()
+}
+
+// SetConfig applies a reloaded config. Settings that are read when a game is
+// created only affect new games.
+func SetConfig(cfg *config.Config) {
+	currentSettings.Store(cfg)
+}
+
+// Reload reloads the config and the word lists, set by main.
+var Reload = func() error { return nil }
+
 var (
 	Commands = []*discordgo.ApplicationCommand{
 		{
@@ -27,19 +47,36 @@ var (
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
 					Name:        "bingo-type",
-					Description: "Kind of bingo",
-					Required:    true,
+					Description: "Kind of bingo, defaults to the type set in /settings",
+					// The bingo types are suggested from the word lists, which can change at runtime
+					Autocomplete: true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "difficulty",
+					Description: "Balances all boards to the same difficulty",
 					Choices: []*discordgo.ApplicationCommandOptionChoice{
 						{
-							Name:  "valorant",
-							Value: "valorant",
+							Name:  "easy",
+							Value: "easy",
+						},
+						{
+							Name:  "normal",
+							Value: "normal",
 						},
 						{
-							Name:  "sekiro",
-							Value: "sekiro",
+							Name:  "hard",
+							Value: "hard",
 						},
 					},
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "reroll-policy",
+					Description: "Reroll rules of the bingo",
+					// The policies are suggested from the config, which can change at runtime
+					Autocomplete: true,
+				},
 			},
 		},
 		{
@@ -56,23 +93,191 @@ var (
 				},
 			},
 		},
+		{
+			Name:        "series",
+			Description: "Manages a series of bingos with cumulative scoring",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "start",
+					Description: "Starts a new series in this server",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "name",
+							Description: "Name of the series",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "placement-points",
+							Description: "Comma separated points for the 1st, 2nd, ... bingo, e.g. 5,3,1",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "line-points",
+							Description: "Points per completed line",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "cell-points",
+							Description: "Points per completed cell",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Adds a bingo to the running series",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "bingo-id",
+							Description: "ID of the bingo",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "show",
+					Description: "Shows the standings of the running series",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "close",
+					Description: "Closes the running series and shows the final standings",
+				},
+			},
+		},
+		{
+			Name:        "stats",
+			Description: "Shows the bingo stats of a player in this server",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "player",
+					Description: "Player to show, defaults to yourself",
+				},
+			},
+		},
+		{
+			Name:        "leaderboard",
+			Description: "Shows the bingo leaderboard of this server",
+		},
+		{
+			Name:        "handicap",
+			Description: "Gives a player of your running bingo a handicap",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "player",
+					Description: "Player that gets the handicap",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "extra-rerolls",
+					Description: "Rerolls on top of the default rerolls",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "free-cells",
+					Description: "Number of pre-completed cells",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "difficulty",
+					Description: "Generates a new board with this difficulty",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{
+							Name:  "easy",
+							Value: "easy",
+						},
+						{
+							Name:  "normal",
+							Value: "normal",
+						},
+						{
+							Name:  "hard",
+							Value: "hard",
+						},
+					},
+				},
+			},
+		},
 	}
 
-	CommandHandlers = map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate){
-		"create": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	CommandHandlers = map[string]func(p Platform, i *discordgo.InteractionCreate){
+		"create": func(p Platform, i *discordgo.InteractionCreate) {
 			options := i.ApplicationCommandData().Options
 			userID := i.Member.User.ID
 
-			bin, err := bingo.Create(i.GuildID, userID, options[0].StringValue(), 25)
+			kind := ""
+			difficultyLevel := ""
+			policyName := ""
+			for _, opt := range options {
+				if opt.Name == "bingo-type" {
+					kind = opt.StringValue()
+				}
+				if opt.Name == "difficulty" {
+					difficultyLevel = opt.StringValue()
+				}
+				if opt.Name == "reroll-policy" {
+					policyName = opt.StringValue()
+				}
+			}
+			difficulty, err := bingo.ParseDifficulty(difficultyLevel)
+			if err != nil {
+				log.WithError(err).Error("Error parsing difficulty")
+				p.SendMessage(i.ChannelID, textMessage("Error"))
+				return
+			}
+			var policy bingo.RerollPolicy
+			if policyName != "" {
+				policy, err = bingo.PolicyByName(settings().GameSettings, policyName)
+				if err != nil {
+					respondEphemeral(p, i, "Could not create the bingo: "+err.Error())
+					return
+				}
+			}
+
+			bin, err := bingo.Create(settings(), i.GuildID, userID, kind, 0)
 			if err != nil {
 				log.WithError(err).Error("Error creating bingo")
-				s.ChannelMessageSend(i.ChannelID, "Error")
+				respondEphemeral(p, i, "Could not create the bingo: "+err.Error())
 				return
 			}
+			bin.TargetDifficulty = difficulty
+			bin.ChannelId = i.ChannelID
+			if policyName != "" {
+				bin.RerollPolicy = policy
+			}
 
+			if settings().GameSettings.WeightedFields {
+				fields, err := analytics.ForKind(settings().StoragePath, bin.Kind)
+				if err != nil {
+					log.WithError(err).Error("Error collecting field stats")
+				} else {
+					bin.Weights = analytics.Weights(fields)
+				}
+			}
+
+			bin.Store(settings().StoragePath)
 			bingo.AddBingo(bin)
 
-			err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			ser := series.Active(i.GuildID)
+			if ser != nil {
+				err = ser.Add(bin)
+				if err == nil {
+					err = ser.Store(settings().StoragePath)
+				}
+				if err != nil {
+					log.WithError(err).Error("Error adding bingo to series")
+				}
+			}
+
+			err = p.Respond(i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseChannelMessageWithSource,
 				Data: &discordgo.InteractionResponseData{
 					Content: "Bingo created successfully",
@@ -81,99 +286,104 @@ var (
 			})
 			if err != nil {
 				log.WithError(err).Error("Error sending response")
-				s.ChannelMessageSend(i.ChannelID, "Error")
+				p.SendMessage(i.ChannelID, textMessage("Error"))
 				return
 			}
 
-			dmChannel, err := s.UserChannelCreate(userID)
+			_, err = p.SendDM(userID, textMessage("Here is the link to your Bingo boards Management plane: "+settings().BaseUrl+"/main/"+bin.Id+"/?pass="+bin.Password))
 			if err != nil {
-				log.WithError(err).Error("Could not create Userchannel")
+				log.WithError(err).Error("Could not send the management link")
 				return
 			}
-			s.ChannelMessageSend(dmChannel.ID, "Here is the link to your Bingo boards Management plane: http://droppel.net:8080/main/"+bin.Id+"/?pass="+bin.Password)
+			spectatorLink := settings().BaseUrl + "/spectate/" + bin.Id
+			if bin.SpectatorKey != "" {
+				spectatorLink += "?key=" + bin.SpectatorKey
+			}
+			p.SendDM(userID, textMessage("Share this link with your spectators: "+spectatorLink))
 
-			msg, err := s.ChannelMessageSend(i.ChannelID, "Bingo created with id: "+bin.Id+". React with 🎫 to join.")
-			MessageToBingo[msg.ID] = bin
+			msg, err := p.SendMessage(i.ChannelID, textMessage("Bingo created with id: "+bin.Id+". React with "+bin.JoinEmoji+" to join."))
 			if err != nil {
 				log.WithError(err).Error("Error sending the message")
-				s.ChannelMessageSend(i.ChannelID, "Error")
+				p.SendMessage(i.ChannelID, textMessage("Error"))
 				return
 			}
+			MessageToBingo[msg.ID] = bin
 
-			err = s.MessageReactionAdd(msg.ChannelID, msg.ID, "🎫")
+			err = p.AddReaction(msg.ChannelID, msg.ID, reactionEmoji(bin.JoinEmoji))
 			if err != nil {
 				log.WithError(err).Error("Error reacting to the message")
 				return
 			}
 		},
-		"continue": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"continue": func(p Platform, i *discordgo.InteractionCreate) {
 			options := i.ApplicationCommandData().Options
-			jsonBingo, err := ioutil.ReadFile(config.Json.StoragePath + options[0].StringValue())
+			jsonBingo, err := ioutil.ReadFile(settings().StoragePath + options[0].StringValue())
 			if err != nil {
 				log.WithError(err).Error("Error reading bingo")
-				s.ChannelMessageSend(i.ChannelID, "Error")
+				p.SendMessage(i.ChannelID, textMessage("Error"))
 				return
 			}
 
-			var bin *bingo.Bingo = &bingo.Bingo{}
-			err = json.Unmarshal(jsonBingo, bin)
+			bin, err := bingo.Decode(jsonBingo)
 			if err != nil {
 				log.WithError(err).Error("Error unmarshaling bingo")
-				s.ChannelMessageSend(i.ChannelID, "Error")
+				p.SendMessage(i.ChannelID, textMessage("Error"))
 				return
 			}
 
+			bin.ChannelId = i.ChannelID
 			bingo.AddBingo(bin)
 
-			msg, err := s.ChannelMessageSend(i.ChannelID, "Bingo continued with id: "+bin.Id+". React with 🎫 to join.")
+			msg, err := p.SendMessage(i.ChannelID, textMessage("Bingo continued with id: "+bin.Id+". React with "+bin.JoinEmoji+" to join."))
 			if err != nil {
 				log.WithError(err).Error("Error sending the message")
-				s.ChannelMessageSend(i.ChannelID, "Error")
+				p.SendMessage(i.ChannelID, textMessage("Error"))
 				return
 			}
 			MessageToBingo[msg.ID] = bin
 
-			err = s.MessageReactionAdd(msg.ChannelID, msg.ID, "🎫")
+			err = p.AddReaction(msg.ChannelID, msg.ID, reactionEmoji(bin.JoinEmoji))
 			if err != nil {
 				log.WithError(err).Error("Error reacting to the message")
 				return
 			}
 		},
+		"series":      handleSeries,
+		"stats":       handleStats,
+		"leaderboard": handleLeaderboard,
+		"handicap":    handleHandicap,
 	}
 )
 
 var (
-	MessageToBingo map[string]*bingo.Bingo
+	// MessageToBingo maps the announcement messages of running bingos to the bingo players join by reacting.
+	MessageToBingo = make(map[string]*bingo.Bingo)
 	dg             *discordgo.Session
-	buffer         = make([][]byte, 0)
-)
-
-func InitBot() {
-	err := loadSound()
-	if err != nil {
-		log.WithError(err).Fatal("Failed to load sound")
-	}
 
-	MessageToBingo = make(map[string]*bingo.Bingo)
+	// Broadcast sends a message to the websocket clients of a bingo. It is set by the httpserver.
+	Broadcast = func(bingoId string, message []byte) {}
+)
 
-	authtoken, err := ioutil.ReadFile("authtoken.txt")
-	if err != nil {
-		log.WithError(err).Error("Could no load authtoken")
-	}
+// Start connects the bot to discord and registers its commands.
+// It returns once the bot is running, Stop closes the connection.
+func Start(cfg *config.Config, token string) error {
+	SetConfig(cfg)
 
 	// Create a new Discord session using the provided bot token.
-	dg, err = discordgo.New("Bot " + string(authtoken))
+	var err error
+	dg, err = discordgo.New("Bot " + token)
 	if err != nil {
-		log.WithError(err).Error("error creating Discord session")
-		return
+		return fmt.Errorf("could not create the discord session: %w", err)
 	}
+	dg.Client.Timeout = restTimeout
+	SetPlatform(&discordPlatform{session: dg})
 
 	// Register the messageCreate func as a callback for MessageCreate events.
-	dg.AddHandler(reactionAdded)
+	dg.AddHandler(func(s *discordgo.Session, rea *discordgo.MessageReactionAdd) {
+		reactionAdded(platform, rea)
+	})
 	dg.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		if h, ok := CommandHandlers[i.ApplicationCommandData().Name]; ok {
-			h(s, i)
-		}
+		handleInteraction(platform, i)
 	})
 	dg.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
 		fmt.Printf("Logged in as: %v#%v\n", s.State.User.Username, s.State.User.Discriminator)
@@ -191,144 +401,331 @@ func InitBot() {
 	// Open a websocket connection to Discord and begin listening.
 	err = dg.Open()
 	if err != nil {
-		log.WithError(err).Error("error opening connection")
-		return
+		return fmt.Errorf("could not connect to discord: %w", err)
+	}
+
+	err = registerCommands(settings().Bot.Guilds)
+	if err != nil {
+		dg.Close()
+		return err
 	}
 
-	log.Info("Adding commands...")
-	registeredCommands := make([]*discordgo.ApplicationCommand, len(Commands))
-	for i, v := range Commands {
-		cmd, err := dg.ApplicationCommandCreate(dg.State.User.ID, "", v)
+	log.Info("Bot is now running.")
+	return nil
+}
+
+// handleInteraction dispatches commands, autocompletions and components to their handlers.
+func handleInteraction(p Platform, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		if h, ok := CommandHandlers[i.ApplicationCommandData().Name]; ok {
+			h(p, i)
+		}
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		if h, ok := AutocompleteHandlers[i.ApplicationCommandData().Name]; ok {
+			h(p, i)
+		}
+	case discordgo.InteractionMessageComponent:
+		args := strings.Split(i.MessageComponentData().CustomID, ":")
+		if h, ok := ComponentHandlers[args[0]]; ok {
+			h(p, i, args[1:])
+		}
+	}
+}
+
+// Stop closes the connection to discord. The commands stay registered for the next start.
+func Stop() {
+	if dg != nil {
+		dg.Close()
+	}
+}
+
+// registerCommands replaces the registered commands with the current ones. Overwriting
+// makes starting the bot again idempotent. With an allow-list the commands are only
+// registered in those guilds and the global commands of earlier starts are removed.
+func registerCommands(guilds []string) error {
+	appId := dg.State.User.ID
+	if len(guilds) == 0 {
+		_, err := dg.ApplicationCommandBulkOverwrite(appId, "", Commands)
 		if err != nil {
-			log.Warnf("Cannot create '%s' command: %v", v.Name, err)
-			return
+			return fmt.Errorf("could not register the commands: %w", err)
 		}
-		registeredCommands[i] = cmd
-		log.Infof("Added command: %s", v.Name)
-	}
-
-	// Wait here until CTRL-C or other term signal is received.
-	log.Info("Bot is now running.  Press CTRL-C to exit.")
-	sc := make(chan os.Signal, 1)
-	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
-	<-sc
-
-	// Cleanly close down the Discord session.
-	dg.Close()
-	log.Println("Removing commands...")
-	// // We need to fetch the commands, since deleting requires the command ID.
-	// // We are doing this from the returned commands on line 375, because using
-	// // this will delete all the commands, which might not be desirable, so we
-	// // are deleting only the commands that we added.
-	// registeredCommands, err := s.ApplicationCommands(s.State.User.ID, *GuildID)
-	// if err != nil {
-	// 	log.Fatalf("Could not fetch registered commands: %v", err)
-	// }
-
-	for _, v := range registeredCommands {
-		err := dg.ApplicationCommandDelete(dg.State.User.ID, "", v.ID)
+		log.Infof("Registered %d commands globally", len(Commands))
+		return nil
+	}
+
+	_, err := dg.ApplicationCommandBulkOverwrite(appId, "", []*discordgo.ApplicationCommand{})
+	if err != nil {
+		return fmt.Errorf("could not remove the global commands: %w", err)
+	}
+	for _, guild := range guilds {
+		_, err := dg.ApplicationCommandBulkOverwrite(appId, guild, Commands)
 		if err != nil {
-			log.Panicf("Cannot delete '%v' command: %v", v.Name, err)
+			return fmt.Errorf("could not register the commands in guild %s: %w", guild, err)
 		}
-		fmt.Printf("Removed command: %s", v.Name)
+		log.Infof("Registered %d commands in guild %s", len(Commands), guild)
 	}
+	return nil
 }
 
-// loadSound attempts to load an encoded sound file from disk.
-func loadSound() error {
+// autocompleteCreate suggests the bingo types or the reroll policies of the current config.
+func autocompleteCreate(p Platform, i *discordgo.InteractionCreate) {
+	if opt := focusedOption(i.ApplicationCommandData().Options); opt == nil || opt.Name != "reroll-policy" {
+		autocompleteKinds(p, i)
+		return
+	}
 
-	file, err := os.Open("data/BrimstoneBingo.dca")
+	names := make([]string, 0, len(settings().GameSettings.RerollPolicies))
+	for name := range settings().GameSettings.RerollPolicies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	typed := strings.ToLower(focusedOption(i.ApplicationCommandData().Options).StringValue())
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, maxChoices)
+	for _, name := range names {
+		if len(choices) >= maxChoices {
+			break
+		}
+		if strings.Contains(strings.ToLower(name), typed) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: name})
+		}
+	}
+
+	err := p.Respond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
 	if err != nil {
-		fmt.Println("Error opening dca file :", err)
-		return err
+		log.WithError(err).Error("Error sending autocomplete choices")
 	}
+}
 
-	var opuslen int16
+func handleSeries(p Platform, i *discordgo.InteractionCreate) {
+	subCommand := i.ApplicationCommandData().Options[0]
+	options := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, opt := range subCommand.Options {
+		options[opt.Name] = opt
+	}
 
-	for {
-		// Read opus frame length from dca file.
-		err = binary.Read(file, binary.LittleEndian, &opuslen)
+	ser := series.Active(i.GuildID)
+	if subCommand.Name != "start" && ser == nil {
+		respond(p, i, "There is no running series in this server. Start one with /series start.")
+		return
+	}
+	if (subCommand.Name == "add" || subCommand.Name == "close") && !canManageSeries(ser, i) {
+		respondEphemeral(p, i, "Only the host of the series, moderators and server managers can change it.")
+		return
+	}
+
+	switch subCommand.Name {
+	case "start":
+		if ser != nil {
+			respond(p, i, "The series '"+ser.Name+"' is still running. Close it with /series close first.")
+			return
+		}
 
-		// If this is the end of the file, just return.
-		if err == io.EOF || err == io.ErrUnexpectedEOF {
-			err := file.Close()
+		scoring := series.Scoring{
+			PlacementPoints: settings().GameSettings.SeriesScoring.PlacementPoints,
+			LinePoints:      settings().GameSettings.SeriesScoring.LinePoints,
+			CellPoints:      settings().GameSettings.SeriesScoring.CellPoints,
+		}
+		if opt, ok := options["placement-points"]; ok {
+			points, err := parsePoints(opt.StringValue())
 			if err != nil {
-				return err
+				respond(p, i, "Invalid placement points: "+err.Error())
+				return
 			}
-			return nil
+			scoring.PlacementPoints = points
+		}
+		if opt, ok := options["line-points"]; ok {
+			scoring.LinePoints = int(opt.IntValue())
+		}
+		if opt, ok := options["cell-points"]; ok {
+			scoring.CellPoints = int(opt.IntValue())
 		}
 
+		ser = series.Create(i.GuildID, i.Member.User.ID, options["name"].StringValue(), scoring)
+		respond(p, i, "Series '"+ser.Name+"' started. New bingos in this server are added automatically. Standings: "+settings().BaseUrl+"/series/"+ser.Id)
+	case "add":
+		bin, err := bingo.Get(settings().StoragePath, options["bingo-id"].StringValue())
 		if err != nil {
-			fmt.Println("Error reading from dca file :", err)
-			return err
+			log.WithError(err).Error("Error loading bingo")
+			respond(p, i, "Could not find that bingo")
+			return
 		}
-
-		// Read encoded pcm from dca file.
-		InBuf := make([]byte, opuslen)
-		err = binary.Read(file, binary.LittleEndian, &InBuf)
-
-		// Should not be any end of file errors
+		err = ser.Add(bin)
 		if err != nil {
-			fmt.Println("Error reading from dca file :", err)
-			return err
+			respond(p, i, "Could not add bingo: "+err.Error())
+			return
 		}
+		respond(p, i, "Added bingo "+bin.Id+" to series '"+ser.Name+"'")
+	case "show":
+		respond(p, i, formatStandings(ser))
+		return
+	case "close":
+		ser.Closed = true
+		respond(p, i, "Series closed.\n"+formatStandings(ser))
+	}
 
-		// Append encoded pcm data to the buffer.
-		buffer = append(buffer, InBuf)
+	err := ser.Store(settings().StoragePath)
+	if err != nil {
+		log.WithError(err).Error("Error storing series")
 	}
 }
 
-func BingoFinished(bin *bingo.Bingo) error {
-	voiceState, err := dg.State.VoiceState(bin.GuildId, bin.OwnerId)
-	if err != nil {
-		return err
+// canManageSeries reports whether the user of the interaction may add bingos to or close the series.
+func canManageSeries(ser *series.Series, i *discordgo.InteractionCreate) bool {
+	return ser.OwnerId == i.Member.User.ID ||
+		bingo.Guild(i.GuildID).IsModerator(i.Member.Roles) ||
+		canManageServer(i)
+}
+
+func handleStats(p Platform, i *discordgo.InteractionCreate) {
+	user := i.Member.User
+	options := i.ApplicationCommandData().Options
+	if len(options) > 0 {
+		user = optionUser(p, options[0])
 	}
 
-	// Join the provided voice channel.
-	vc, err := dg.ChannelVoiceJoin(bin.GuildId, voiceState.ChannelID, false, true)
+	player, err := stats.ForPlayer(settings().StoragePath, i.GuildID, user.ID)
+	if err == stats.ErrNoStats {
+		respond(p, i, user.Username+" has not played any bingo in this server yet.")
+		return
+	}
 	if err != nil {
-		return err
+		log.WithError(err).Error("Error collecting stats")
+		respond(p, i, "Could not collect the stats")
+		return
 	}
 
-	// Sleep for a specified amount of time before playing the sound
-	time.Sleep(250 * time.Millisecond)
+	fastest := "-"
+	if player.FastestBingo > 0 {
+		fastest = (time.Duration(player.FastestBingo) * time.Second).String()
+	}
+	respond(p, i, "**"+player.UserName+"**\n"+
+		"Games played: "+strconv.Itoa(player.Games)+"\n"+
+		"Wins: "+strconv.Itoa(player.Wins)+"\n"+
+		"Average cells completed: "+strconv.FormatFloat(player.AverageCells, 'f', 1, 64)+"\n"+
+		"Rerolls used: "+strconv.Itoa(player.RerollsUsed)+"\n"+
+		"Fastest bingo: "+fastest)
+}
 
-	// Start speaking.
-	err = vc.Speaking(true)
+func handleLeaderboard(p Platform, i *discordgo.InteractionCreate) {
+	leaderboard, err := stats.Leaderboard(settings().StoragePath, i.GuildID)
 	if err != nil {
-		return err
+		log.WithError(err).Error("Error collecting stats")
+		respond(p, i, "Could not collect the stats")
+		return
 	}
 
-	// Send the buffer data.
-	for _, buff := range buffer {
-		vc.OpusSend <- buff
+	text := "**Leaderboard**\n"
+	for place, player := range leaderboard {
+		if place >= 10 {
+			break
+		}
+		text += strconv.Itoa(place+1) + ". " + player.UserName + ": " + strconv.Itoa(player.Wins) + " wins in " + strconv.Itoa(player.Games) + " games\n"
 	}
+	respond(p, i, text+settings().BaseUrl+"/leaderboard/"+i.GuildID)
+}
 
-	// Stop speaking
-	err = vc.Speaking(false)
-	if err != nil {
-		return err
+func handleHandicap(p Platform, i *discordgo.InteractionCreate) {
+	bin := bingo.Latest(i.GuildID)
+	if bin == nil || bin.OwnerId != i.Member.User.ID {
+		respond(p, i, "You are not hosting a running bingo in this server.")
+		return
+	}
+
+	options := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, opt := range i.ApplicationCommandData().Options {
+		options[opt.Name] = opt
+	}
+
+	user := optionUser(p, options["player"])
+	bin.Lock()
+	defer bin.Unlock()
+	board, exists := bin.Boards[user.ID]
+	if !exists {
+		respond(p, i, user.Username+" has not joined the bingo.")
+		return
+	}
+
+	handicap := board.Handicap
+	if opt, ok := options["extra-rerolls"]; ok {
+		handicap.ExtraRerolls = int(opt.IntValue())
+	}
+	if opt, ok := options["free-cells"]; ok {
+		handicap.FreeCells = int(opt.IntValue())
+	}
+	if opt, ok := options["difficulty"]; ok {
+		difficulty, err := bingo.ParseDifficulty(opt.StringValue())
+		if err != nil {
+			respond(p, i, err.Error())
+			return
+		}
+		handicap.Difficulty = difficulty
 	}
 
-	// Sleep for a specificed amount of time before ending.
-	time.Sleep(250 * time.Millisecond)
+	err := bin.SetHandicap(board, handicap)
+	if err != nil {
+		respond(p, i, "Could not set the handicap: "+err.Error())
+		return
+	}
+	bin.UpdateWinners()
+	Broadcast(bin.Id, []byte("Handicap"))
+	bin.Store(settings().StoragePath)
+	RefreshBoards(bin)
+
+	description := handicap.String()
+	if description == "" {
+		description = "none"
+	}
+	respond(p, i, "Handicap of "+board.UserName+": "+description)
+}
 
-	// Disconnect from the provided voice channel.
-	err = vc.Disconnect()
+func formatStandings(ser *series.Series) string {
+	standings, err := ser.Standings(settings().StoragePath)
 	if err != nil {
-		return err
+		log.WithError(err).Error("Error computing standings")
+		return "Could not compute the standings"
 	}
 
-	return err
+	text := "**" + ser.Name + "** (" + strconv.Itoa(len(ser.Bingos)) + " games)\n"
+	for place, standing := range standings {
+		text += strconv.Itoa(place+1) + ". " + standing.UserName + ": " + strconv.Itoa(standing.Points) + " points\n"
+	}
+	return text + settings().BaseUrl + "/series/" + ser.Id
 }
 
-func reactionAdded(s *discordgo.Session, rea *discordgo.MessageReactionAdd) {
+func parsePoints(value string) ([]int, error) {
+	points := make([]int, 0)
+	for _, part := range strings.Split(value, ",") {
+		point, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
 
-	if rea.UserID == s.State.User.ID {
-		return
+func respond(p Platform, i *discordgo.InteractionCreate, content string) {
+	err := p.Respond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+	if err != nil {
+		log.WithError(err).Error("Error sending response")
 	}
+}
+
+func reactionAdded(p Platform, rea *discordgo.MessageReactionAdd) {
 
-	if rea.Emoji.Name != "🎫" {
+	if rea.UserID == p.BotUserId() {
 		return
 	}
 
@@ -338,19 +735,29 @@ func reactionAdded(s *discordgo.Session, rea *discordgo.MessageReactionAdd) {
 		return
 	}
 
-	dmChannel, err := s.UserChannelCreate(rea.UserID)
-	if err != nil {
-		log.WithError(err).Error("Could not create Userchannel")
+	if rea.Emoji.APIName() != reactionEmoji(bin.JoinEmoji) {
 		return
 	}
 
-	user, err := s.User(rea.UserID)
+	userName, err := p.UserName(rea.UserID)
 	if err != nil {
 		return
 	}
 
-	board := bin.CreateBoard(rea.UserID, user.Username, config.Json.GameSettings.TotalRerolls)
+	bin.Lock()
+	board := bin.CreateBoard(rea.UserID, userName)
+	bin.Store(settings().StoragePath)
+	bin.Unlock()
 
-	s.ChannelMessageSend(dmChannel.ID, "Here is a link to your Bingo board: http://droppel.net:8080/bingo/"+bin.Id+"/"+board.Id+"?pass="+board.Password)
+	_, err = p.SendDM(rea.UserID, textMessage("Here is a link to your Bingo board: "+settings().BaseUrl+"/bingo/"+bin.Id+"/"+board.Id+"?pass="+board.Password))
+	if err != nil {
+		log.WithError(err).Error("Could not send the board link")
+		return
+	}
+
+	err = SendBoard(bin, board)
+	if err != nil {
+		log.WithError(err).Error("Could not send the board")
+	}
 
 }
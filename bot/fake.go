@@ -0,0 +1,208 @@
+package bot
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// FakeMessage is a message sent on a FakePlatform.
+type FakeMessage struct {
+	Id        string
+	ChannelId string
+	// UserId is the receiver of a direct message, empty for channel messages.
+	UserId  string
+	Message *discordgo.MessageSend
+}
+
+// FakeReaction is a reaction added on a FakePlatform.
+type FakeReaction struct {
+	ChannelId string
+	MessageId string
+	Emoji     string
+}
+
+// FakePlayback is a sound played on a FakePlatform.
+type FakePlayback struct {
+	GuildId   string
+	ChannelId string
+	Frames    int
+}
+
+// FakePlatform is an in-memory Platform that records every call instead of
+// talking to discord. It is safe for concurrent use.
+type FakePlatform struct {
+	lock          sync.Mutex
+	botId         string
+	userNames     map[string]string
+	voiceChannels map[string]string
+	voiceError    error
+	editErrors    map[string]error
+	nextId        int
+
+	messages  []FakeMessage
+	edits     []*discordgo.MessageEdit
+	reactions []FakeReaction
+	responses []*discordgo.InteractionResponse
+	playbacks []FakePlayback
+}
+
+// NewFakePlatform returns a platform without users in voice channels. Users are named after their id.
+func NewFakePlatform(botId string) *FakePlatform {
+	return &FakePlatform{
+		botId:         botId,
+		userNames:     make(map[string]string),
+		voiceChannels: make(map[string]string),
+		editErrors:    make(map[string]error),
+	}
+}
+
+// SetUserName sets the name UserName returns for the user.
+func (f *FakePlatform) SetUserName(userId, userName string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.userNames[userId] = userName
+}
+
+// SetVoiceChannel puts the user into the voice channel, an empty channel removes the user from voice.
+func (f *FakePlatform) SetVoiceChannel(guildId, userId, channelId string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.voiceChannels[guildId+"/"+userId] = channelId
+}
+
+// FailVoice makes PlayVoice return the error, nil lets it succeed again.
+func (f *FakePlatform) FailVoice(err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.voiceError = err
+}
+
+// Messages returns the channel messages and direct messages in the order they were sent.
+func (f *FakePlatform) Messages() []FakeMessage {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]FakeMessage{}, f.messages...)
+}
+
+// DMs returns the direct messages sent to the user.
+func (f *FakePlatform) DMs(userId string) []FakeMessage {
+	dms := make([]FakeMessage, 0)
+	for _, message := range f.Messages() {
+		if message.UserId == userId {
+			dms = append(dms, message)
+		}
+	}
+	return dms
+}
+
+// Edits returns all edited messages.
+func (f *FakePlatform) Edits() []*discordgo.MessageEdit {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]*discordgo.MessageEdit{}, f.edits...)
+}
+
+// Reactions returns the reactions added by the bot.
+func (f *FakePlatform) Reactions() []FakeReaction {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]FakeReaction{}, f.reactions...)
+}
+
+// Responses returns the responses to interactions.
+func (f *FakePlatform) Responses() []*discordgo.InteractionResponse {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]*discordgo.InteractionResponse{}, f.responses...)
+}
+
+// Playbacks returns the sounds played in voice channels.
+func (f *FakePlatform) Playbacks() []FakePlayback {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]FakePlayback{}, f.playbacks...)
+}
+
+func (f *FakePlatform) BotUserId() string {
+	return f.botId
+}
+
+func (f *FakePlatform) UserName(userId string) (string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if userName, exists := f.userNames[userId]; exists {
+		return userName, nil
+	}
+	return userId, nil
+}
+
+func (f *FakePlatform) SendDM(userId string, message *discordgo.MessageSend) (*discordgo.Message, error) {
+	return f.send("dm-"+userId, userId, message), nil
+}
+
+func (f *FakePlatform) SendMessage(channelId string, message *discordgo.MessageSend) (*discordgo.Message, error) {
+	return f.send(channelId, "", message), nil
+}
+
+func (f *FakePlatform) send(channelId, userId string, message *discordgo.MessageSend) *discordgo.Message {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.nextId++
+	id := "message-" + strconv.Itoa(f.nextId)
+	f.messages = append(f.messages, FakeMessage{Id: id, ChannelId: channelId, UserId: userId, Message: message})
+	return &discordgo.Message{ID: id, ChannelID: channelId, Content: message.Content}
+}
+
+// FailEdit makes EditMessage return the error for the message, e.g. because it was deleted.
+func (f *FakePlatform) FailEdit(messageId string, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.editErrors[messageId] = err
+}
+
+func (f *FakePlatform) EditMessage(edit *discordgo.MessageEdit) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.editErrors[edit.ID]; err != nil {
+		return err
+	}
+	f.edits = append(f.edits, edit)
+	return nil
+}
+
+func (f *FakePlatform) AddReaction(channelId, messageId, emoji string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.reactions = append(f.reactions, FakeReaction{ChannelId: channelId, MessageId: messageId, Emoji: emoji})
+	return nil
+}
+
+func (f *FakePlatform) Respond(interaction *discordgo.Interaction, response *discordgo.InteractionResponse) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.responses = append(f.responses, response)
+	return nil
+}
+
+func (f *FakePlatform) VoiceChannel(guildId, userId string) (string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	channelId := f.voiceChannels[guildId+"/"+userId]
+	if channelId == "" {
+		return "", errors.New("not in a voice channel")
+	}
+	return channelId, nil
+}
+
+func (f *FakePlatform) PlayVoice(guildId, channelId string, frames [][]byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.voiceError != nil {
+		return f.voiceError
+	}
+	f.playbacks = append(f.playbacks, FakePlayback{GuildId: guildId, ChannelId: channelId, Frames: len(frames)})
+	return nil
+}
@@ -0,0 +1,217 @@
+package bot
+
+import (
+	"Bingo/bingo"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// maxChoices is the maximum number of autocomplete choices discord accepts.
+	maxChoices = 25
+)
+
+var (
+	markCommands = []*discordgo.ApplicationCommand{
+		{
+			Name:        "mark",
+			Description: "Marks a field of your running bingo as completed",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "field",
+					Description:  "Field to mark",
+					Required:     true,
+					Autocomplete: true,
+				},
+			},
+		},
+		{
+			Name:        "unmark",
+			Description: "Marks a field of your running bingo as not completed",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "field",
+					Description:  "Field to unmark",
+					Required:     true,
+					Autocomplete: true,
+				},
+			},
+		},
+		{
+			Name:        "moderator",
+			Description: "Allows another user to mark fields of your running bingo",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Adds a moderator",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        "user",
+							Description: "User that may mark fields",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Removes a moderator",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        "user",
+							Description: "User that may no longer mark fields",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	AutocompleteHandlers = map[string]func(p Platform, i *discordgo.InteractionCreate){
+		"mark":   autocompleteFields(false),
+		"unmark": autocompleteFields(true),
+	}
+)
+
+func init() {
+	Commands = append(Commands, markCommands...)
+	CommandHandlers["mark"] = handleMark(true)
+	CommandHandlers["unmark"] = handleMark(false)
+	CommandHandlers["moderator"] = handleModerator
+}
+
+// moderatedBingo returns the running bingo of the guild if the user may mark its fields.
+func moderatedBingo(i *discordgo.InteractionCreate) *bingo.Bingo {
+	bin := bingo.Latest(i.GuildID)
+	if bin == nil {
+		return nil
+	}
+	bin.Lock()
+	canModerate := bin.CanModerate(i.Member.User.ID)
+	bin.Unlock()
+	if !canModerate && !bingo.Guild(i.GuildID).IsModerator(i.Member.Roles) {
+		return nil
+	}
+	return bin
+}
+
+func handleMark(value bool) func(p Platform, i *discordgo.InteractionCreate) {
+	return func(p Platform, i *discordgo.InteractionCreate) {
+		bin := moderatedBingo(i)
+		if bin == nil {
+			respondEphemeral(p, i, "You are not hosting or moderating a running bingo in this server.")
+			return
+		}
+
+		field := strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue())
+		bin.Lock()
+		current, exists := bin.Completed[field]
+		bin.Unlock()
+		if !exists {
+			respondEphemeral(p, i, "'"+field+"' is not a field of this bingo.")
+			return
+		}
+		if current == value {
+			respondEphemeral(p, i, "'"+field+"' is already in that state.")
+			return
+		}
+
+		// Respond before setting the field, as announcing a bingo takes longer
+		// than discord waits for the response.
+		if value {
+			respond(p, i, "✅ "+field)
+		} else {
+			respond(p, i, "❌ "+field)
+		}
+		SetField(bin, field, value)
+	}
+}
+
+// autocompleteFields suggests the fields of the running bingo that match the
+// typed text and have the given completion state.
+func autocompleteFields(completed bool) func(p Platform, i *discordgo.InteractionCreate) {
+	return func(p Platform, i *discordgo.InteractionCreate) {
+		choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, maxChoices)
+
+		bin := moderatedBingo(i)
+		if bin != nil {
+			bin.Lock()
+			typed := strings.ToLower(i.ApplicationCommandData().Options[0].StringValue())
+			for _, word := range bin.Words {
+				if len(choices) >= maxChoices {
+					break
+				}
+				if bin.Completed[word] != completed || !strings.Contains(strings.ToLower(word), typed) {
+					continue
+				}
+				choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+					Name:  word,
+					Value: word,
+				})
+			}
+			bin.Unlock()
+		}
+
+		err := p.Respond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+			Data: &discordgo.InteractionResponseData{
+				Choices: choices,
+			},
+		})
+		if err != nil {
+			log.WithError(err).Error("Error sending autocomplete")
+		}
+	}
+}
+
+func handleModerator(p Platform, i *discordgo.InteractionCreate) {
+	bin := bingo.Latest(i.GuildID)
+	if bin == nil || bin.OwnerId != i.Member.User.ID {
+		respondEphemeral(p, i, "You are not hosting a running bingo in this server.")
+		return
+	}
+
+	subCommand := i.ApplicationCommandData().Options[0]
+	user := optionUser(p, subCommand.Options[0])
+
+	bin.Lock()
+	moderators := make([]string, 0, len(bin.Moderators))
+	for _, moderator := range bin.Moderators {
+		if moderator != user.ID {
+			moderators = append(moderators, moderator)
+		}
+	}
+	if subCommand.Name == "add" {
+		moderators = append(moderators, user.ID)
+	}
+	bin.Moderators = moderators
+	bin.Store(settings().StoragePath)
+	bin.Unlock()
+
+	if subCommand.Name == "add" {
+		respondEphemeral(p, i, user.Username+" can now mark fields.")
+	} else {
+		respondEphemeral(p, i, user.Username+" can no longer mark fields.")
+	}
+}
+
+func respondEphemeral(p Platform, i *discordgo.InteractionCreate, content string) {
+	err := p.Respond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.WithError(err).Error("Error sending response")
+	}
+}
@@ -0,0 +1,162 @@
+package bot
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Platform is everything the bot does on the chat platform. The handlers only
+// talk to discord through it so they can be run against FakePlatform.
+type Platform interface {
+	// BotUserId returns the user id of the bot itself.
+	BotUserId() string
+	UserName(userId string) (string, error)
+	SendDM(userId string, message *discordgo.MessageSend) (*discordgo.Message, error)
+	SendMessage(channelId string, message *discordgo.MessageSend) (*discordgo.Message, error)
+	EditMessage(edit *discordgo.MessageEdit) error
+	AddReaction(channelId, messageId, emoji string) error
+	Respond(interaction *discordgo.Interaction, response *discordgo.InteractionResponse) error
+	// VoiceChannel returns the voice channel the user is in or an error if the user is in none.
+	VoiceChannel(guildId, userId string) (string, error)
+	// PlayVoice joins the voice channel, plays the opus frames and leaves again.
+	PlayVoice(guildId, channelId string, frames [][]byte) error
+}
+
+// platform is the platform the bot runs on, nil until the bot is started.
+var platform Platform
+
+// SetPlatform replaces the platform, Start sets it to discord.
+func SetPlatform(p Platform) {
+	platform = p
+}
+
+// textMessage is a message with only content.
+func textMessage(content string) *discordgo.MessageSend {
+	return &discordgo.MessageSend{Content: content}
+}
+
+// discordPlatform runs the bot on a discordgo session.
+type discordPlatform struct {
+	session *discordgo.Session
+}
+
+func (d *discordPlatform) BotUserId() string {
+	return d.session.State.User.ID
+}
+
+func (d *discordPlatform) UserName(userId string) (string, error) {
+	user, err := d.session.User(userId)
+	if err != nil {
+		return "", err
+	}
+	return user.Username, nil
+}
+
+func (d *discordPlatform) SendDM(userId string, message *discordgo.MessageSend) (*discordgo.Message, error) {
+	dmChannel, err := d.session.UserChannelCreate(userId)
+	if err != nil {
+		return nil, err
+	}
+	return d.session.ChannelMessageSendComplex(dmChannel.ID, message)
+}
+
+func (d *discordPlatform) SendMessage(channelId string, message *discordgo.MessageSend) (*discordgo.Message, error) {
+	return d.session.ChannelMessageSendComplex(channelId, message)
+}
+
+func (d *discordPlatform) EditMessage(edit *discordgo.MessageEdit) error {
+	_, err := d.session.ChannelMessageEditComplex(edit)
+	return err
+}
+
+func (d *discordPlatform) AddReaction(channelId, messageId, emoji string) error {
+	return d.session.MessageReactionAdd(channelId, messageId, emoji)
+}
+
+func (d *discordPlatform) Respond(interaction *discordgo.Interaction, response *discordgo.InteractionResponse) error {
+	return d.session.InteractionRespond(interaction, response)
+}
+
+func (d *discordPlatform) VoiceChannel(guildId, userId string) (string, error) {
+	voiceState, err := d.session.State.VoiceState(guildId, userId)
+	if err != nil {
+		return "", err
+	}
+	if voiceState.ChannelID == "" {
+		return "", errors.New("not in a voice channel")
+	}
+	return voiceState.ChannelID, nil
+}
+
+func (d *discordPlatform) PlayVoice(guildId, channelId string, frames [][]byte) error {
+	vc, err := d.joinVoice(guildId, channelId)
+	if err != nil {
+		return err
+	}
+	defer vc.Disconnect()
+
+	// Sleep for a specified amount of time before playing the sound
+	time.Sleep(250 * time.Millisecond)
+
+	// Start speaking.
+	err = vc.Speaking(true)
+	if err != nil {
+		return err
+	}
+
+	for _, frame := range frames {
+		select {
+		case vc.OpusSend <- frame:
+		case <-time.After(frameTimeout):
+			vc.Speaking(false)
+			return errors.New("timeout sending the sound")
+		}
+	}
+
+	// Stop speaking
+	err = vc.Speaking(false)
+	if err != nil {
+		return err
+	}
+
+	// Sleep for a specificed amount of time before ending.
+	time.Sleep(250 * time.Millisecond)
+	return nil
+}
+
+// joinVoice joins the voice channel or gives up after voiceJoinTimeout.
+func (d *discordPlatform) joinVoice(guildId, channelId string) (*discordgo.VoiceConnection, error) {
+	type result struct {
+		vc  *discordgo.VoiceConnection
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		vc, err := d.session.ChannelVoiceJoin(guildId, channelId, false, true)
+		done <- result{vc, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.vc, r.err
+	case <-time.After(voiceJoinTimeout):
+		// Leave the channel again if the join succeeds after all
+		go func() {
+			if r := <-done; r.vc != nil {
+				r.vc.Disconnect()
+			}
+		}()
+		return nil, errors.New("timeout joining the voice channel")
+	}
+}
+
+// optionUser returns the user of a user option with the name looked up on the platform.
+func optionUser(p Platform, option *discordgo.ApplicationCommandInteractionDataOption) *discordgo.User {
+	user := option.UserValue(nil)
+	if userName, err := p.UserName(user.ID); err == nil {
+		user.Username = userName
+	}
+	return user
+}
@@ -0,0 +1,500 @@
+package bot
+
+import (
+	"Bingo/bingo"
+	"Bingo/config"
+	"Bingo/series"
+	"Bingo/sound"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	testGuild   = "100"
+	testChannel = "200"
+	testOwner   = "300"
+	testPlayer  = "400"
+	testBot     = "500"
+)
+
+// setupFake runs the bot on a fake platform with an empty storage.
+func setupFake(t *testing.T) *FakePlatform {
+	cfg := config.Default()
+	cfg.WordsPath = "../bingos/"
+	cfg.StoragePath = t.TempDir() + "/"
+	SetConfig(cfg)
+	bingo.Bingos = make(map[string]*bingo.Bingo)
+	MessageToBingo = make(map[string]*bingo.Bingo)
+
+	fake := NewFakePlatform(testBot)
+	fake.SetUserName(testPlayer, "player")
+	SetPlatform(fake)
+	t.Cleanup(func() { SetPlatform(nil) })
+	return fake
+}
+
+func command(name string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommand,
+		GuildID:   testGuild,
+		ChannelID: testChannel,
+		Member:    &discordgo.Member{User: &discordgo.User{ID: testOwner}},
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name:    name,
+			Options: options,
+		},
+	}}
+}
+
+func stringOption(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{
+		Name:  name,
+		Type:  discordgo.ApplicationCommandOptionString,
+		Value: value,
+	}
+}
+
+// joinMessage returns the message players react to and fails if there is none.
+func joinMessage(t *testing.T, fake *FakePlatform) FakeMessage {
+	for _, message := range fake.Messages() {
+		if message.ChannelId == testChannel && strings.Contains(message.Message.Content, "to join") {
+			return message
+		}
+	}
+	t.Fatal("expected a message to join the bingo")
+	return FakeMessage{}
+}
+
+func react(messageId, userId, emoji string) *discordgo.MessageReactionAdd {
+	return &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+		UserID:    userId,
+		MessageID: messageId,
+		ChannelID: testChannel,
+		GuildID:   testGuild,
+		Emoji:     discordgo.Emoji{Name: emoji},
+	}}
+}
+
+// waitFor polls the condition as the announcements are sent in the background.
+func waitFor(t *testing.T, description string, condition func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for " + description)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	fake := setupFake(t)
+
+	handleInteraction(fake, command("create", stringOption("bingo-type", "valorant")))
+
+	if len(bingo.Bingos) != 1 {
+		t.Fatalf("expected one running bingo, got %d", len(bingo.Bingos))
+	}
+	var bin *bingo.Bingo
+	for _, running := range bingo.Bingos {
+		bin = running
+	}
+	if bin.OwnerId != testOwner || bin.Kind != "valorant" || bin.ChannelId != testChannel {
+		t.Errorf("unexpected bingo %s by %s in %s", bin.Kind, bin.OwnerId, bin.ChannelId)
+	}
+	if _, err := bingo.Load(settings().StoragePath, bin.Id); err != nil {
+		t.Errorf("expected the bingo to be stored: %v", err)
+	}
+
+	responses := fake.Responses()
+	if len(responses) != 1 || responses[0].Data.Flags != discordgo.MessageFlagsEphemeral {
+		t.Errorf("expected one ephemeral response, got %d", len(responses))
+	}
+	dms := fake.DMs(testOwner)
+	if len(dms) != 2 || !strings.Contains(dms[0].Message.Content, "/main/"+bin.Id+"/?pass="+bin.Password) {
+		t.Errorf("expected the management and spectator links as direct messages, got %d messages", len(dms))
+	}
+
+	message := joinMessage(t, fake)
+	if MessageToBingo[message.Id] != bin {
+		t.Errorf("expected the message to join the bingo")
+	}
+	reactions := fake.Reactions()
+	if len(reactions) != 1 || reactions[0].MessageId != message.Id || reactions[0].Emoji != bingo.DefaultJoinEmoji {
+		t.Errorf("expected the join emoji on the message, got %v", reactions)
+	}
+}
+
+func TestCreateRerollPolicies(t *testing.T) {
+	fake := setupFake(t)
+	cfg := config.Default()
+	cfg.WordsPath = "../bingos/"
+	cfg.StoragePath = t.TempDir() + "/"
+	cfg.GameSettings.RerollPolicies = map[string]config.RerollPolicy{
+		"classic": {},
+		"locked":  {LockWhenRunning: true},
+		"dynamic": {RegenerateSeconds: 60, MaxRerolls: 5},
+	}
+	SetConfig(cfg)
+
+	focused := stringOption("reroll-policy", "")
+	focused.Focused = true
+	autocomplete := command("create", stringOption("bingo-type", "valorant"), focused)
+	autocomplete.Type = discordgo.InteractionApplicationCommandAutocomplete
+	handleInteraction(fake, autocomplete)
+
+	responses := fake.Responses()
+	if len(responses) != 1 || responses[0].Type != discordgo.InteractionApplicationCommandAutocompleteResult {
+		t.Fatalf("expected the autocomplete choices, got %d responses", len(responses))
+	}
+	var names []string
+	for _, choice := range responses[0].Data.Choices {
+		names = append(names, choice.Name)
+	}
+	if strings.Join(names, ",") != "classic,dynamic,locked" {
+		t.Errorf("expected the policies of the config, got %v", names)
+	}
+
+	handleInteraction(fake, command("create", stringOption("bingo-type", "valorant"), stringOption("reroll-policy", "unknown")))
+	if len(bingo.Bingos) != 0 {
+		t.Errorf("expected no bingo with an unknown reroll policy")
+	}
+
+	handleInteraction(fake, command("create", stringOption("bingo-type", "valorant"), stringOption("reroll-policy", "locked")))
+	for _, bin := range bingo.Bingos {
+		if !bin.RerollPolicy.LockWhenRunning {
+			t.Errorf("expected the locked reroll policy, got %+v", bin.RerollPolicy)
+		}
+	}
+	if len(bingo.Bingos) != 1 {
+		t.Errorf("expected one running bingo, got %d", len(bingo.Bingos))
+	}
+}
+
+func TestContinue(t *testing.T) {
+	fake := setupFake(t)
+	bin, err := bingo.Create(settings(), testGuild, testOwner, "valorant", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin.Store(settings().StoragePath)
+
+	handleInteraction(fake, command("continue", stringOption("bingo-id", bin.Kind+"_"+bin.Id+".json")))
+
+	continued := bingo.Bingos[bin.Id]
+	if continued == nil || continued.ChannelId != testChannel {
+		t.Fatal("expected the bingo to run in the channel")
+	}
+	message := joinMessage(t, fake)
+	if !strings.Contains(message.Message.Content, "continued with id: "+bin.Id) {
+		t.Errorf("unexpected message %q", message.Message.Content)
+	}
+	if MessageToBingo[message.Id] != continued {
+		t.Errorf("expected the message to join the continued bingo")
+	}
+	if len(fake.Reactions()) != 1 {
+		t.Errorf("expected the join emoji on the message")
+	}
+}
+
+func TestJoinByReaction(t *testing.T) {
+	fake := setupFake(t)
+	handleInteraction(fake, command("create", stringOption("bingo-type", "valorant")))
+	message := joinMessage(t, fake)
+	bin := MessageToBingo[message.Id]
+
+	reactionAdded(fake, react(message.Id, testBot, bingo.DefaultJoinEmoji))
+	reactionAdded(fake, react(message.Id, testPlayer, "👍"))
+	if len(bin.Boards) != 0 {
+		t.Fatal("expected the reactions of the bot and with other emojis to be ignored")
+	}
+
+	reactionAdded(fake, react(message.Id, testPlayer, bingo.DefaultJoinEmoji))
+	board := bin.Boards[testPlayer]
+	if board == nil || board.UserName != "player" {
+		t.Fatal("expected a board for the player")
+	}
+	dms := fake.DMs(testPlayer)
+	if len(dms) != 2 {
+		t.Fatalf("expected the link and the board as direct messages, got %d", len(dms))
+	}
+	if !strings.Contains(dms[0].Message.Content, "/bingo/"+bin.Id+"/"+board.Id+"?pass="+board.Password) {
+		t.Errorf("unexpected link %q", dms[0].Message.Content)
+	}
+	if !strings.Contains(dms[1].Message.Content, "player") {
+		t.Errorf("expected the board message, got %q", dms[1].Message.Content)
+	}
+}
+
+func TestJoinEmojiOfCreation(t *testing.T) {
+	fake := setupFake(t)
+	handleInteraction(fake, command("create", stringOption("bingo-type", "valorant")))
+	message := joinMessage(t, fake)
+	bin := MessageToBingo[message.Id]
+
+	if err := bingo.SetGuild(settings().StoragePath, bingo.GuildSettings{GuildId: testGuild, JoinEmoji: "🎲"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { bingo.SetGuild(settings().StoragePath, bingo.GuildSettings{GuildId: testGuild}) })
+
+	reactionAdded(fake, react(message.Id, testPlayer, "🎲"))
+	if len(bin.Boards) != 0 {
+		t.Fatal("expected the new join emoji to be ignored by a running bingo")
+	}
+	reactionAdded(fake, react(message.Id, testPlayer, bingo.DefaultJoinEmoji))
+	if bin.Boards[testPlayer] == nil {
+		t.Errorf("expected the player to join with the emoji of the join message")
+	}
+}
+
+// startGame creates a bingo with a board for the player and one for somebody else so a line is not the end.
+func startGame(t *testing.T) (*bingo.Bingo, *bingo.BingoBoard) {
+	bin, err := bingo.Create(settings(), testGuild, testOwner, "valorant", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin.ChannelId = testChannel
+	bingo.AddBingo(bin)
+	bin.CreateBoard("600", "other")
+	return bin, bin.CreateBoard(testPlayer, "player")
+}
+
+// markRow marks the first row of the board.
+func markRow(bin *bingo.Bingo, board *bingo.BingoBoard) {
+	width := bingo.Guild(testGuild).BoardWidth
+	for _, word := range board.Content[:width] {
+		SetField(bin, word, true)
+	}
+}
+
+func TestWinAnnouncement(t *testing.T) {
+	fake := setupFake(t)
+	library := sound.LoadLibrary("../data/")
+	if len(library.Names()) == 0 {
+		t.Skip("no sounds in ../data/")
+	}
+	sound.SetLibrary(library)
+	t.Cleanup(func() { sound.SetLibrary(sound.LoadLibrary("")) })
+	fake.SetVoiceChannel(testGuild, testPlayer, "voice")
+
+	bin, board := startGame(t)
+	markRow(bin, board)
+
+	waitFor(t, "the sound", func() bool { return len(fake.Playbacks()) > 0 })
+	playback := fake.Playbacks()[0]
+	if playback.ChannelId != "voice" || playback.Frames == 0 {
+		t.Errorf("expected the sound in the voice channel of the player, got %+v", playback)
+	}
+	messages := fake.Messages()
+	if len(messages) != 1 || messages[0].ChannelId != testChannel || !strings.Contains(messages[0].Message.Content, "BINGO** for player") {
+		t.Fatalf("expected one announcement in the channel of the bingo, got %v", messages)
+	}
+	if len(messages[0].Message.Files) != 1 {
+		t.Errorf("expected the board as image")
+	}
+}
+
+func TestWinAnnouncementWithoutVoice(t *testing.T) {
+	fake := setupFake(t)
+	sound.SetLibrary(sound.LoadLibrary("../data/"))
+	t.Cleanup(func() { sound.SetLibrary(sound.LoadLibrary("")) })
+	fake.SetVoiceChannel(testGuild, testOwner, "voice")
+	fake.FailVoice(errors.New("voice unavailable"))
+
+	bin, board := startGame(t)
+	markRow(bin, board)
+
+	waitFor(t, "the fallback", func() bool { return len(fake.Messages()) >= 2 })
+	fallback := fake.Messages()[1]
+	if fallback.ChannelId != testChannel || fallback.Message.Content != fallbackTexts[sound.EventFirst] {
+		t.Errorf("expected the fallback text in the channel, got %q", fallback.Message.Content)
+	}
+}
+
+func subCommand(name string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{
+		Name:    name,
+		Type:    discordgo.ApplicationCommandOptionSubCommand,
+		Options: options,
+	}
+}
+
+func TestSeriesNeedsHost(t *testing.T) {
+	fake := setupFake(t)
+	series.AllSeries = make(map[string]*series.Series)
+	handleInteraction(fake, command("series", subCommand("start", stringOption("name", "season"))))
+	ser := series.Active(testGuild)
+	if ser == nil || ser.OwnerId != testOwner {
+		t.Fatal("expected a series hosted by the owner")
+	}
+
+	byPlayer := command("series", subCommand("close"))
+	byPlayer.Member.User.ID = testPlayer
+	handleInteraction(fake, byPlayer)
+	if ser.Closed {
+		t.Fatal("expected another player not to close the series")
+	}
+
+	byManager := command("series", subCommand("close"))
+	byManager.Member.User.ID = testPlayer
+	byManager.Member.Permissions = discordgo.PermissionManageServer
+	handleInteraction(fake, byManager)
+	if !ser.Closed {
+		t.Errorf("expected a server manager to close the series")
+	}
+}
+
+func selectCell(customId, value string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionMessageComponent,
+		ChannelID: testChannel,
+		User:      &discordgo.User{ID: testPlayer},
+		Data: discordgo.MessageComponentInteractionData{
+			CustomID: customId,
+			Values:   []string{value},
+		},
+	}}
+}
+
+func TestRerollMenu(t *testing.T) {
+	fake := setupFake(t)
+	bin, board := startGame(t)
+	long := strings.Repeat("very long field ", 10)
+	if err := bin.ReplaceWord(board.Content[0], long); err != nil {
+		t.Fatal(err)
+	}
+	board.Rerolls = 5
+
+	menu := rerollMenu(bin, board)
+	if menu == nil {
+		t.Fatal("expected a reroll menu")
+	}
+	for _, option := range menu.Options {
+		if len([]rune(option.Label)) > 100 || len(option.Value) > 100 {
+			t.Errorf("expected labels and values of at most 100 characters, got %q", option.Value)
+		}
+	}
+
+	value := menu.Options[0].Value
+	handleInteraction(fake, selectCell(menu.CustomID, value))
+	if board.Content[0] == long || board.Rerolls != 4 {
+		t.Fatalf("expected the long field to be rerolled, got %q", board.Content[0])
+	}
+
+	rerolled := board.Content[0]
+	handleInteraction(fake, selectCell(menu.CustomID, value))
+	responses := fake.Responses()
+	if board.Content[0] != rerolled || !strings.Contains(responses[len(responses)-1].Data.Content, "changed") {
+		t.Errorf("expected an outdated menu to be rejected")
+	}
+	handleInteraction(fake, selectCell(menu.CustomID, "99:x"))
+	if board.Rerolls != 4 {
+		t.Errorf("expected an invalid cell to be rejected")
+	}
+}
+
+func TestBoardMessageEdits(t *testing.T) {
+	fake := setupFake(t)
+	bin, board := startGame(t)
+	other := bin.Boards["600"]
+	for _, send := range []*bingo.BingoBoard{board, other} {
+		if err := SendBoard(bin, send); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deleted := fake.DMs(other.Id)[0].Id
+	fake.FailEdit(deleted, errors.New("unknown message"))
+
+	for _, word := range board.Content[:2] {
+		SetField(bin, word, true)
+	}
+	if err := SendBoard(bin, board); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the board edits", func() bool {
+		boardMessagesLock.Lock()
+		defer boardMessagesLock.Unlock()
+		return !editing[bin.Id]
+	})
+
+	boardMessagesLock.Lock()
+	messages := append([]boardMessage(nil), boardMessages[bin.Id]...)
+	boardMessagesLock.Unlock()
+	if len(messages) != 2 || messages[0].BoardId != board.Id || messages[1].BoardId != board.Id {
+		t.Fatalf("expected only the failed message to be forgotten, got %+v", messages)
+	}
+
+	var last *discordgo.MessageEdit
+	for _, edit := range fake.Edits() {
+		if edit.ID == messages[0].MessageId {
+			last = edit
+		}
+	}
+	if last == nil || !strings.Contains(last.Embeds[0].Description, "✅ ~~"+board.Content[1]+"~~") {
+		t.Errorf("expected the last edit to show both completed fields")
+	}
+}
+
+func TestSettingsNeedManageServer(t *testing.T) {
+	fake := setupFake(t)
+	t.Cleanup(func() { bingo.SetGuild(settings().StoragePath, bingo.GuildSettings{GuildId: testGuild}) })
+	width := &discordgo.ApplicationCommandInteractionDataOption{
+		Name:  "board-size",
+		Type:  discordgo.ApplicationCommandOptionInteger,
+		Value: float64(bingo.MinBoardWidth),
+	}
+
+	handleInteraction(fake, command("settings", subCommand("set", width)))
+	if bingo.Guild(testGuild).BoardWidth == bingo.MinBoardWidth {
+		t.Fatal("expected a user without Manage Server not to change the settings")
+	}
+
+	byManager := command("settings", subCommand("set", width))
+	byManager.Member.Permissions = discordgo.PermissionManageServer
+	handleInteraction(fake, byManager)
+	if bingo.Guild(testGuild).BoardWidth != bingo.MinBoardWidth {
+		t.Errorf("expected a server manager to change the settings")
+	}
+}
+
+func TestConcurrentToggles(t *testing.T) {
+	setupFake(t)
+	bin, board := startGame(t)
+	field := board.Content[0]
+
+	done := make(chan bool)
+	for n := 0; n < 2; n++ {
+		go func() { done <- ToggleField(bin, field) }()
+	}
+	for n := 0; n < 2; n++ {
+		if !<-done {
+			t.Fatal("expected the field to be toggled")
+		}
+	}
+	bin.Lock()
+	defer bin.Unlock()
+	if bin.Completed[field] {
+		t.Errorf("expected two toggles to leave the field unmarked")
+	}
+}
+
+func TestAutomationKeyForHost(t *testing.T) {
+	fake := setupFake(t)
+	bin, _ := startGame(t)
+
+	byPlayer := command("automation-key")
+	byPlayer.Member.User.ID = testPlayer
+	handleInteraction(fake, byPlayer)
+	handleInteraction(fake, command("automation-key"))
+
+	responses := fake.Responses()
+	if len(responses) != 2 || strings.Contains(responses[0].Data.Content, bin.ApiKey) {
+		t.Fatal("expected the key not to be shown to a player")
+	}
+	if !strings.Contains(responses[1].Data.Content, bin.ApiKey) || responses[1].Data.Flags != discordgo.MessageFlagsEphemeral {
+		t.Errorf("expected the key to be shown to the host only, got %q", responses[1].Data.Content)
+	}
+}
@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"Bingo/bingo"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	adminPermission int64 = discordgo.PermissionAdministrator
+
+	reloadCommand = &discordgo.ApplicationCommand{
+		Name:                     "reload",
+		Description:              "Reloads the config and the word lists for new bingos",
+		DefaultMemberPermissions: &adminPermission,
+	}
+)
+
+func init() {
+	Commands = append(Commands, reloadCommand)
+	CommandHandlers["reload"] = handleReload
+	AutocompleteHandlers["create"] = autocompleteCreate
+}
+
+func handleReload(p Platform, i *discordgo.InteractionCreate) {
+	err := Reload()
+	if err != nil {
+		log.WithError(err).Error("Reload failed")
+		respondEphemeral(p, i, "Reload failed, the previous config stays active: "+err.Error())
+		return
+	}
+	respondEphemeral(p, i, "Config and word lists reloaded. Changes apply to new bingos.")
+}
+
+// autocompleteKinds suggests the bingo types of the current word lists.
+func autocompleteKinds(p Platform, i *discordgo.InteractionCreate) {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, maxChoices)
+
+	typed := ""
+	if opt := focusedOption(i.ApplicationCommandData().Options); opt != nil {
+		typed = strings.ToLower(opt.StringValue())
+	}
+	if catalog := bingo.CurrentCatalog(); catalog != nil {
+		for _, kind := range catalog.Kinds() {
+			if len(choices) >= maxChoices {
+				break
+			}
+			if strings.Contains(strings.ToLower(kind), typed) {
+				choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: kind, Value: kind})
+			}
+		}
+	}
+
+	err := p.Respond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+	if err != nil {
+		log.WithError(err).Error("Error sending autocomplete choices")
+	}
+}
+
+// focusedOption returns the option being typed, also inside of subcommands.
+func focusedOption(options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, opt := range options {
+		if opt.Focused {
+			return opt
+		}
+		if focused := focusedOption(opt.Options); focused != nil {
+			return focused
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package bot
+
+import (
+	"Bingo/bingo"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	manageServerPermission int64 = discordgo.PermissionManageServer
+	noDMs                        = false
+	minBoardWidth                = float64(bingo.MinBoardWidth)
+	minRerolls                   = 0.0
+
+	settingsCommand = &discordgo.ApplicationCommand{
+		Name:                     "settings",
+		Description:              "Defaults for the bingos of this server",
+		DefaultMemberPermissions: &manageServerPermission,
+		DMPermission:             &noDMs,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Shows the settings of this server",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Changes the settings of this server",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "bingo-type",
+						Description:  "Kind of bingo used if /create does not name one",
+						Autocomplete: true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "board-size",
+						Description: "Number of fields per row",
+						MinValue:    &minBoardWidth,
+						MaxValue:    bingo.MaxBoardWidth,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "rerolls",
+						Description: "Rerolls every player starts with",
+						MinValue:    &minRerolls,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "win-pattern",
+						Description: "Fields needed for a bingo",
+						Choices:     winPatternChoices(),
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "announcement-channel",
+						Description:  "Channel the bingos are announced in",
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "join-emoji",
+						Description: "Reaction players join a bingo with",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "voice-sound",
+						Description: "Play a sound in the voice channel of the host on a bingo",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "moderator-role",
+				Description: "Allows or disallows a role to mark fields in every bingo",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "The role",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "allowed",
+						Description: "Whether the role may mark fields",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "reset",
+				Description: "Resets all settings of this server to the defaults",
+			},
+		},
+	}
+)
+
+func init() {
+	Commands = append(Commands, settingsCommand)
+	CommandHandlers["settings"] = handleSettings
+	AutocompleteHandlers["settings"] = autocompleteKinds
+}
+
+func winPatternChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(bingo.WinPatterns))
+	for _, name := range bingo.WinPatternNames() {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  name,
+			Value: name,
+		})
+	}
+	return choices
+}
+
+// canManageServer reports whether the user of the interaction has the Manage Server
+// permission. Guild admins can override the default permissions of a command, so the
+// handlers check it again.
+func canManageServer(i *discordgo.InteractionCreate) bool {
+	return i.Member != nil && i.Member.Permissions&discordgo.PermissionManageServer != 0
+}
+
+func handleSettings(p Platform, i *discordgo.InteractionCreate) {
+	if !canManageServer(i) {
+		respondEphemeral(p, i, "Only server managers can change this.")
+		return
+	}
+	subCommand := i.ApplicationCommandData().Options[0]
+	options := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, opt := range subCommand.Options {
+		options[opt.Name] = opt
+	}
+
+	guild := bingo.Guild(i.GuildID)
+	switch subCommand.Name {
+	case "show":
+		respondEphemeral(p, i, formatGuildSettings(guild))
+		return
+	case "set":
+		if opt, ok := options["bingo-type"]; ok {
+			kind := opt.StringValue()
+			if catalog := bingo.CurrentCatalog(); catalog != nil {
+				if _, exists := catalog.Lists[kind]; !exists {
+					respondEphemeral(p, i, "Unknown bingo type "+kind)
+					return
+				}
+			}
+			guild.DefaultKind = kind
+		}
+		if opt, ok := options["board-size"]; ok {
+			guild.BoardWidth = int(opt.IntValue())
+		}
+		if opt, ok := options["rerolls"]; ok {
+			rerolls := int(opt.IntValue())
+			guild.TotalRerolls = &rerolls
+		}
+		if opt, ok := options["win-pattern"]; ok {
+			guild.WinPattern = opt.StringValue()
+		}
+		if opt, ok := options["announcement-channel"]; ok {
+			guild.AnnouncementChannel = opt.ChannelValue(nil).ID
+		}
+		if opt, ok := options["join-emoji"]; ok {
+			guild.JoinEmoji = strings.TrimSpace(opt.StringValue())
+		}
+		if opt, ok := options["voice-sound"]; ok {
+			guild.DisableSound = !opt.BoolValue()
+		}
+	case "moderator-role":
+		role := options["role"].RoleValue(nil, i.GuildID).ID
+		roles := make([]string, 0, len(guild.ModeratorRoles)+1)
+		for _, existing := range guild.ModeratorRoles {
+			if existing != role {
+				roles = append(roles, existing)
+			}
+		}
+		if options["allowed"].BoolValue() {
+			roles = append(roles, role)
+		}
+		guild.ModeratorRoles = roles
+	case "reset":
+		guild = bingo.GuildSettings{GuildId: i.GuildID}
+	}
+
+	err := bingo.SetGuild(settings().StoragePath, guild)
+	if err != nil {
+		log.WithError(err).Error("Error storing guild settings")
+		respondEphemeral(p, i, "Could not change the settings: "+err.Error())
+		return
+	}
+	respondEphemeral(p, i, "Settings saved. They apply to new bingos.\n"+formatGuildSettings(bingo.Guild(i.GuildID)))
+}
+
+func formatGuildSettings(guild bingo.GuildSettings) string {
+	kind := guild.DefaultKind
+	if kind == "" {
+		kind = "none"
+	}
+	rerolls := strconv.Itoa(settings().GameSettings.TotalRerolls) + " (default)"
+	if guild.TotalRerolls != nil {
+		rerolls = strconv.Itoa(*guild.TotalRerolls)
+	}
+	channel := "channel of the bingo"
+	if guild.AnnouncementChannel != "" {
+		channel = "<#" + guild.AnnouncementChannel + ">"
+	}
+	roles := "none"
+	if len(guild.ModeratorRoles) > 0 {
+		roles = "<@&" + strings.Join(guild.ModeratorRoles, ">, <@&") + ">"
+	}
+
+	return "Bingo type: " + kind + "\n" +
+		"Board size: " + strconv.Itoa(guild.BoardWidth) + "x" + strconv.Itoa(guild.BoardWidth) + "\n" +
+		"Rerolls: " + rerolls + "\n" +
+		"Win pattern: " + guild.WinPattern + "\n" +
+		"Announcements: " + channel + "\n" +
+		"Join emoji: " + guild.JoinEmoji + "\n" +
+		"Voice sound: " + strconv.FormatBool(!guild.DisableSound) + "\n" +
+		"Moderator roles: " + roles
+}
+
+// reactionEmoji converts an emoji as written in a message, e.g. <:bingo:1234>,
+// to the form the reaction api uses. Unicode emojis are returned unchanged.
+func reactionEmoji(emoji string) string {
+	if strings.HasPrefix(emoji, "<") && strings.HasSuffix(emoji, ">") {
+		emoji = strings.TrimPrefix(strings.Trim(emoji, "<>"), "a")
+		return strings.TrimPrefix(emoji, ":")
+	}
+	return emoji
+}
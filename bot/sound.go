@@ -0,0 +1,227 @@
+package bot
+
+import (
+	"Bingo/bingo"
+	"Bingo/sound"
+	"bytes"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	soundCommand = &discordgo.ApplicationCommand{
+		Name:                     "sound",
+		Description:              "Sounds played in the voice channel of the host",
+		DefaultMemberPermissions: &manageServerPermission,
+		DMPermission:             &noDMs,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "Lists the sounds of the library and the ones selected in this server",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "preview",
+				Description: "Sends you a sound as file so you can listen to it at your own volume",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "name",
+						Description:  "Name of the sound",
+						Required:     true,
+						Autocomplete: true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Selects the sound of an event in this server",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "event",
+						Description: "When the sound is played",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "first bingo", Value: sound.EventFirst},
+							{Name: "later bingos and lines", Value: sound.EventLine},
+							{Name: "every player has a bingo", Value: sound.EventEnd},
+						},
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "name",
+						Description:  "Name of the sound, none for silence",
+						Required:     true,
+						Autocomplete: true,
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "bingo-type",
+						Description:  "Only use the sound for this kind of bingo",
+						Autocomplete: true,
+					},
+				},
+			},
+		},
+	}
+)
+
+func init() {
+	Commands = append(Commands, soundCommand)
+	CommandHandlers["sound"] = handleSound
+	AutocompleteHandlers["sound"] = autocompleteSound
+}
+
+// soundFor returns the name of the sound for the event of the bingo. The guild
+// settings for the kind come first, then the guild, then the config.
+func soundFor(bin *bingo.Bingo, event string) string {
+	guild := bingo.Guild(bin.GuildId)
+	if name := guild.KindSounds[bin.Kind][event]; name != "" {
+		return name
+	}
+	if name := guild.Sounds[event]; name != "" {
+		return name
+	}
+	if name := settings().Sound.Kinds[bin.Kind][event]; name != "" {
+		return name
+	}
+	return settings().Sound.Events[event]
+}
+
+func handleSound(p Platform, i *discordgo.InteractionCreate) {
+	if !canManageServer(i) {
+		respondEphemeral(p, i, "Only server managers can change this.")
+		return
+	}
+	subCommand := i.ApplicationCommandData().Options[0]
+	options := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, opt := range subCommand.Options {
+		options[opt.Name] = opt
+	}
+	library := sound.Current()
+
+	switch subCommand.Name {
+	case "list":
+		text := "Sounds: " + strings.Join(library.Names(), ", ") + "\n"
+		if len(library.Names()) == 0 {
+			text = "The sound library is empty, no sounds are played.\n"
+		}
+		guild := bingo.Guild(i.GuildID)
+		for _, event := range sound.Events {
+			if name := guild.Sounds[event]; name != "" {
+				text += event + ": " + name + "\n"
+			}
+		}
+		for kind, sounds := range guild.KindSounds {
+			for event, name := range sounds {
+				text += kind + " " + event + ": " + name + "\n"
+			}
+		}
+		respondEphemeral(p, i, text)
+	case "preview":
+		name := options["name"].StringValue()
+		frames, exists := library.Get(name)
+		if !exists {
+			respondEphemeral(p, i, "There is no sound "+name)
+			return
+		}
+		file := &bytes.Buffer{}
+		err := sound.WriteOgg(file, frames)
+		if err != nil {
+			log.WithError(err).Error("Error converting sound")
+			respondEphemeral(p, i, "Could not convert the sound")
+			return
+		}
+		err = p.Respond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Preview of " + name,
+				Flags:   discordgo.MessageFlagsEphemeral,
+				Files: []*discordgo.File{{
+					Name:        name + ".ogg",
+					ContentType: "audio/ogg",
+					Reader:      file,
+				}},
+			},
+		})
+		if err != nil {
+			log.WithError(err).Error("Error sending sound preview")
+		}
+	case "set":
+		event := options["event"].StringValue()
+		name := options["name"].StringValue()
+		if _, exists := library.Get(name); !exists && name != sound.None {
+			respondEphemeral(p, i, "There is no sound "+name)
+			return
+		}
+
+		guild := bingo.Guild(i.GuildID)
+		if opt, ok := options["bingo-type"]; ok {
+			kind := opt.StringValue()
+			kindSounds := make(map[string]map[string]string)
+			for existingKind, sounds := range guild.KindSounds {
+				kindSounds[existingKind] = sounds
+			}
+			kindSounds[kind] = copySounds(guild.KindSounds[kind])
+			kindSounds[kind][event] = name
+			guild.KindSounds = kindSounds
+		} else {
+			guild.Sounds = copySounds(guild.Sounds)
+			guild.Sounds[event] = name
+		}
+
+		err := bingo.SetGuild(settings().StoragePath, guild)
+		if err != nil {
+			log.WithError(err).Error("Error storing guild settings")
+			respondEphemeral(p, i, "Could not change the sound: "+err.Error())
+			return
+		}
+		respondEphemeral(p, i, "The sound for "+event+" is now "+name)
+	}
+}
+
+// copySounds copies the sound selection so the stored guild settings are never modified in place.
+func copySounds(sounds map[string]string) map[string]string {
+	copied := make(map[string]string, len(sounds)+1)
+	for event, name := range sounds {
+		copied[event] = name
+	}
+	return copied
+}
+
+func autocompleteSound(p Platform, i *discordgo.InteractionCreate) {
+	focused := focusedOption(i.ApplicationCommandData().Options)
+	if focused != nil && focused.Name == "bingo-type" {
+		autocompleteKinds(p, i)
+		return
+	}
+
+	typed := ""
+	if focused != nil {
+		typed = strings.ToLower(focused.StringValue())
+	}
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, maxChoices)
+	for _, name := range append(sound.Current().Names(), sound.None) {
+		if len(choices) >= maxChoices {
+			break
+		}
+		if strings.Contains(strings.ToLower(name), typed) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: name})
+		}
+	}
+
+	err := p.Respond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+	if err != nil {
+		log.WithError(err).Error("Error sending autocomplete choices")
+	}
+}
@@ -0,0 +1,148 @@
+package bot
+
+import (
+	"Bingo/bingo"
+	"Bingo/sound"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// announcementQueueSize is the number of announcements a guild can have
+	// waiting. Further announcements are dropped until the queue drains.
+	announcementQueueSize = 8
+	voiceJoinTimeout      = 10 * time.Second
+	// frameTimeout aborts the playback if discord stops accepting frames.
+	frameTimeout = time.Second
+	// restTimeout limits every request to the discord api.
+	restTimeout = 10 * time.Second
+)
+
+// fallbackTexts are posted instead of a sound if nobody of the bingo is in a voice channel.
+var fallbackTexts = map[string]string{
+	sound.EventFirst: "🔊 The first BINGO!",
+	sound.EventLine:  "🔊 Another line!",
+	sound.EventEnd:   "🔊 Every player has a BINGO, the game is over!",
+}
+
+// announcement is everything posted and played for an event of a bingo. It is
+// collected when the event happens so the worker does not access the bingo.
+type announcement struct {
+	GuildId     string
+	TextChannel string
+	// VoiceUsers are tried in order to find a voice channel, the host comes first.
+	VoiceUsers []string
+	Message    *discordgo.MessageSend
+	Event      string
+	Sound      [][]byte
+}
+
+var (
+	announcementQueues     = make(map[string]chan announcement)
+	announcementQueuesLock sync.Mutex
+)
+
+// newAnnouncement collects the message and the sound of an event of the bingo.
+// The message is nil if there is nothing to post, the sound if nothing should be played.
+func newAnnouncement(bin *bingo.Bingo, message *discordgo.MessageSend, event string) announcement {
+	a := announcement{
+		GuildId:     bin.GuildId,
+		TextChannel: bingo.Guild(bin.GuildId).AnnouncementChannel,
+		VoiceUsers:  []string{bin.OwnerId},
+		Message:     message,
+		Event:       event,
+	}
+	if a.TextChannel == "" {
+		a.TextChannel = bin.ChannelId
+	}
+	for _, board := range bin.Boards {
+		if !board.IsAnonymous() && board.Id != bin.OwnerId {
+			a.VoiceUsers = append(a.VoiceUsers, board.Id)
+		}
+	}
+
+	name := soundFor(bin, event)
+	if event == "" || bingo.Guild(bin.GuildId).DisableSound || name == "" || name == sound.None {
+		return a
+	}
+	frames, exists := sound.Current().Get(name)
+	if !exists {
+		log.WithField("sound", name).WithField("event", event).Warn("Sound not in the library, playing nothing")
+		return a
+	}
+	a.Sound = frames
+	return a
+}
+
+// enqueueAnnouncement hands the announcement to the worker of its guild without
+// waiting for discord. The worker plays one announcement after the other so
+// two quick bingos never race for the voice connection.
+func enqueueAnnouncement(a announcement) {
+	if platform == nil || (a.Message == nil && a.Sound == nil) {
+		return
+	}
+
+	announcementQueuesLock.Lock()
+	queue, exists := announcementQueues[a.GuildId]
+	if !exists {
+		queue = make(chan announcement, announcementQueueSize)
+		announcementQueues[a.GuildId] = queue
+		go announcementWorker(queue)
+	}
+	announcementQueuesLock.Unlock()
+
+	select {
+	case queue <- a:
+	default:
+		log.WithField("guild", a.GuildId).Warn("Announcement queue is full, dropping announcement")
+	}
+}
+
+func announcementWorker(queue chan announcement) {
+	for a := range queue {
+		if a.Message != nil && a.TextChannel != "" {
+			_, err := platform.SendMessage(a.TextChannel, a.Message)
+			if err != nil {
+				log.WithError(err).Error("Failed to announce the winners")
+			}
+		}
+		if a.Sound == nil {
+			continue
+		}
+
+		err := playSound(a)
+		if err != nil {
+			log.WithError(err).Warn("Could not play the sound, announcing as text")
+			if fallback := fallbackTexts[a.Event]; fallback != "" && a.TextChannel != "" {
+				_, err = platform.SendMessage(a.TextChannel, textMessage(fallback))
+				if err != nil {
+					log.WithError(err).Error("Failed to send the fallback announcement")
+				}
+			}
+		}
+	}
+}
+
+// voiceChannel returns the first voice channel one of the users is in.
+func voiceChannel(guildId string, users []string) (string, error) {
+	for _, user := range users {
+		channelId, err := platform.VoiceChannel(guildId, user)
+		if err == nil {
+			return channelId, nil
+		}
+	}
+	return "", errors.New("nobody of the bingo is in a voice channel")
+}
+
+// playSound plays the sound of the announcement in the voice channel of the host or a player.
+func playSound(a announcement) error {
+	channelId, err := voiceChannel(a.GuildId, a.VoiceUsers)
+	if err != nil {
+		return err
+	}
+	return platform.PlayVoice(a.GuildId, channelId, a.Sound)
+}
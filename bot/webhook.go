@@ -0,0 +1,168 @@
+package bot
+
+import (
+	"Bingo/bingo"
+	"Bingo/webhook"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	webhookCommand = &discordgo.ApplicationCommand{
+		Name:                     "webhook",
+		Description:              "Webhooks that receive the events of every bingo in this server",
+		DefaultMemberPermissions: &manageServerPermission,
+		DMPermission:             &noDMs,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Adds a webhook and shows you its signing secret",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "url",
+						Description: "Address the events are posted to",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "events",
+						Description: "Comma separated events, all if empty: " + strings.Join(bingo.WebhookEvents, ", "),
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "Lists the webhooks with their last delivery",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Removes a webhook",
+				Options:     []*discordgo.ApplicationCommandOption{webhookIdOption},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "test",
+				Description: "Sends a test event to a webhook, the result is shown in the list",
+				Options:     []*discordgo.ApplicationCommandOption{webhookIdOption},
+			},
+		},
+	}
+
+	webhookIdOption = &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "id",
+		Description: "Id of the webhook",
+		Required:    true,
+	}
+)
+
+func init() {
+	Commands = append(Commands, webhookCommand)
+	CommandHandlers["webhook"] = handleWebhook
+}
+
+func handleWebhook(p Platform, i *discordgo.InteractionCreate) {
+	if !canManageServer(i) {
+		respondEphemeral(p, i, "Only server managers can change this.")
+		return
+	}
+	subCommand := i.ApplicationCommandData().Options[0]
+	options := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, opt := range subCommand.Options {
+		options[opt.Name] = opt
+	}
+	guild := bingo.Guild(i.GuildID)
+
+	switch subCommand.Name {
+	case "add":
+		events := make([]string, 0)
+		if opt, ok := options["events"]; ok {
+			for _, event := range strings.Split(opt.StringValue(), ",") {
+				if event = strings.TrimSpace(event); event != "" {
+					events = append(events, event)
+				}
+			}
+		}
+		hook, err := bingo.NewWebhook(options["url"].StringValue(), events)
+		if err != nil {
+			respondEphemeral(p, i, "Could not add the webhook: "+err.Error())
+			return
+		}
+		guild.Webhooks = append(append([]bingo.Webhook{}, guild.Webhooks...), hook)
+		if !storeGuild(p, i, guild) {
+			return
+		}
+		respondEphemeral(p, i, "Added webhook "+hook.Id+". Requests are signed in the "+webhook.SignatureHeader+
+			" header with the secret `"+hook.Secret+"`")
+	case "list":
+		if len(guild.Webhooks) == 0 {
+			respondEphemeral(p, i, "This server has no webhooks.")
+			return
+		}
+		text := ""
+		for _, hook := range guild.Webhooks {
+			text += "**" + hook.Id + "** " + hook.Url
+			if len(hook.Events) > 0 {
+				text += " (" + strings.Join(hook.Events, ", ") + ")"
+			}
+			if deliveries := webhook.Deliveries(hook.Id); len(deliveries) > 0 {
+				text += "\nLast delivery: " + describeDelivery(deliveries[0])
+			}
+			text += "\n"
+		}
+		respondEphemeral(p, i, text)
+	case "remove":
+		var removed bool
+		guild.Webhooks, removed = bingo.RemoveWebhook(guild.Webhooks, options["id"].StringValue())
+		if !removed {
+			respondEphemeral(p, i, "There is no webhook "+options["id"].StringValue())
+			return
+		}
+		if !storeGuild(p, i, guild) {
+			return
+		}
+		webhook.Remove(options["id"].StringValue())
+		respondEphemeral(p, i, "Removed the webhook.")
+	case "test":
+		for _, hook := range guild.Webhooks {
+			if hook.Id != options["id"].StringValue() {
+				continue
+			}
+			bin := bingo.Latest(i.GuildID)
+			if bin == nil {
+				bin = &bingo.Bingo{GuildId: i.GuildID}
+			}
+			// The webhook may take longer to answer than discord waits for the response
+			go webhook.Test(hook, bin)
+			respondEphemeral(p, i, "Sending a test event, see /webhook list for the result.")
+			return
+		}
+		respondEphemeral(p, i, "There is no webhook "+options["id"].StringValue())
+	}
+}
+
+// storeGuild stores the guild settings and tells the user if that failed.
+func storeGuild(p Platform, i *discordgo.InteractionCreate, guild bingo.GuildSettings) bool {
+	err := bingo.SetGuild(settings().StoragePath, guild)
+	if err != nil {
+		log.WithError(err).Error("Error storing guild settings")
+		respondEphemeral(p, i, "Could not store the settings: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func describeDelivery(delivery webhook.Delivery) string {
+	text := delivery.Event + " at " + delivery.Time.Format("2006-01-02 15:04:05")
+	if delivery.Delivered {
+		return text + ", delivered"
+	}
+	return text + ", failed after " + strconv.Itoa(delivery.Attempts) + " attempts: " + delivery.Error
+}
@@ -2,35 +2,427 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
 
-type config struct {
-	StoragePath  string       `json:"storagePath"`
-	LogLevel     string       `json:"logLevel"`
-	GameSettings gameSettings `json:"gameSettings"`
+type Config struct {
+	StoragePath string `json:"storagePath"`
+	// WordsPath is the directory with the word lists of the bingo types.
+	WordsPath string `json:"wordsPath"`
+	LogLevel  string `json:"logLevel"`
+	// BaseUrl is the public address of the webserver that links sent to discord point to.
+	BaseUrl string `json:"baseUrl"`
+	// ListenAddress is the address the webserver listens on.
+	ListenAddress string          `json:"listenAddress"`
+	Bot           BotSettings     `json:"bot"`
+	Twitch        TwitchSettings  `json:"twitch"`
+	Sound         SoundSettings   `json:"sound"`
+	GameSettings  GameSettings    `json:"gameSettings"`
+	Automation    Automation      `json:"automation"`
+	Webhooks      WebhookSettings `json:"webhooks"`
 }
 
-type gameSettings struct {
-	TotalRerolls int `json:"totalRerolls"`
+// WebhookSettings restrict the addresses the webhooks are delivered to.
+type WebhookSettings struct {
+	// AllowPrivateNetworks lets webhooks reach loopback, link-local and private
+	// addresses, e.g. for automation running next to the bot.
+	AllowPrivateNetworks bool `json:"allowPrivateNetworks"`
 }
 
-var Json config
+// Automation maps the events scripts send to the automation endpoint to fields.
+type Automation struct {
+	// Aliases maps the bingo types to event names and the field each marks.
+	// Events without an alias mark the field with the same name.
+	Aliases map[string]map[string]string `json:"aliases"`
+}
+
+type SoundSettings struct {
+	// Path is the directory with the dca, ogg and opus files of the sound library.
+	Path string `json:"path"`
+	// Events maps the events first, line and end to the name of the sound played by default.
+	Events map[string]string `json:"events"`
+	// Kinds overrides Events for single bingo types.
+	Kinds map[string]map[string]string `json:"kinds"`
+}
+
+type BotSettings struct {
+	// TokenEnv is the environment variable holding the bot token. It takes precedence over TokenFile.
+	TokenEnv  string `json:"tokenEnv"`
+	TokenFile string `json:"tokenFile"`
+	// Guilds restricts the commands to these guilds. The commands are registered globally if it is empty.
+	Guilds []string `json:"guilds"`
+}
+
+// TwitchSettings connect the twitch chat of streams so viewers can play along.
+type TwitchSettings struct {
+	// Server is the address of the twitch chat, IRC over TLS.
+	Server string `json:"server"`
+	// Nick is the twitch account the bot chats with.
+	Nick string `json:"nick"`
+	// TokenEnv is the environment variable holding the oauth token of Nick. It takes precedence over TokenFile.
+	TokenEnv  string `json:"tokenEnv"`
+	TokenFile string `json:"tokenFile"`
+	// Channels maps the twitch channels to the guild whose latest bingo is played in the chat.
+	// The chat is not connected if it is empty.
+	Channels map[string]string `json:"channels"`
+	// MaxBoards caps the boards viewers create with !join in a single bingo.
+	MaxBoards int `json:"maxBoards"`
+}
+
+type GameSettings struct {
+	TotalRerolls  int           `json:"totalRerolls"`
+	SeriesScoring SeriesScoring `json:"seriesScoring"`
+	// WeightedFields picks fields for new boards based on the statistics of previous games.
+	WeightedFields bool `json:"weightedFields"`
+	// DifficultyTolerance is how far the difficulty sum of a balanced board may be off its target.
+	DifficultyTolerance int `json:"difficultyTolerance"`
+	// RerollPolicies are the named reroll rules selectable on /create.
+	RerollPolicies      map[string]RerollPolicy `json:"rerollPolicies"`
+	DefaultRerollPolicy string                  `json:"defaultRerollPolicy"`
+	// ProtectSpectators requires a separate key for the spectator page of new bingos.
+	ProtectSpectators bool `json:"protectSpectators"`
+}
+
+type RerollPolicy struct {
+	Cost              int  `json:"cost"`
+	SwapCost          int  `json:"swapCost"`
+	CooldownSeconds   int  `json:"cooldownSeconds"`
+	RegenerateSeconds int  `json:"regenerateSeconds"`
+	MaxRerolls        int  `json:"maxRerolls"`
+	LineBonus         int  `json:"lineBonus"`
+	LockWhenRunning   bool `json:"lockWhenRunning"`
+	ExcludeRecent     int  `json:"excludeRecent"`
+}
+
+type SeriesScoring struct {
+	PlacementPoints []int `json:"placementPoints"`
+	LinePoints      int   `json:"linePoints"`
+	CellPoints      int   `json:"cellPoints"`
+}
+
+// Default returns the settings used for everything the config file and the environment do not set.
+func Default() *Config {
+	return &Config{
+		StoragePath:   "./store/",
+		WordsPath:     "bingos/",
+		LogLevel:      "info",
+		BaseUrl:       "http://localhost:8080",
+		ListenAddress: ":8080",
+		Bot: BotSettings{
+			TokenEnv:  "BINGO_TOKEN",
+			TokenFile: "authtoken.txt",
+		},
+		Twitch: TwitchSettings{
+			Server:    "irc.chat.twitch.tv:6697",
+			TokenEnv:  "BINGO_TWITCH_TOKEN",
+			MaxBoards: 100,
+		},
+		Sound: SoundSettings{
+			Path:   "data/",
+			Events: map[string]string{"first": "BrimstoneBingo"},
+		},
+		GameSettings: GameSettings{
+			TotalRerolls:        2,
+			DifficultyTolerance: 2,
+			RerollPolicies: map[string]RerollPolicy{
+				"classic": {Cost: 1},
+			},
+			DefaultRerollPolicy: "classic",
+			SeriesScoring: SeriesScoring{
+				PlacementPoints: []int{5, 3, 1},
+				LinePoints:      1,
+			},
+		},
+	}
+}
+
+// envOverrides are the environment variables that override a setting of the config file.
+var envOverrides = map[string]func(c *Config, value string) error{
+	"BINGO_STORAGE_PATH":   func(c *Config, value string) error { c.StoragePath = value; return nil },
+	"BINGO_WORDS_PATH":     func(c *Config, value string) error { c.WordsPath = value; return nil },
+	"BINGO_SOUND_PATH":     func(c *Config, value string) error { c.Sound.Path = value; return nil },
+	"BINGO_LOG_LEVEL":      func(c *Config, value string) error { c.LogLevel = value; return nil },
+	"BINGO_BASE_URL":       func(c *Config, value string) error { c.BaseUrl = value; return nil },
+	"BINGO_LISTEN_ADDRESS": func(c *Config, value string) error { c.ListenAddress = value; return nil },
+	"BINGO_TOKEN_FILE":     func(c *Config, value string) error { c.Bot.TokenFile = value; return nil },
+	"BINGO_GUILDS": func(c *Config, value string) error {
+		c.Bot.Guilds = make([]string, 0)
+		for _, guild := range strings.Split(value, ",") {
+			if guild = strings.TrimSpace(guild); guild != "" {
+				c.Bot.Guilds = append(c.Bot.Guilds, guild)
+			}
+		}
+		return nil
+	},
+	"BINGO_TOTAL_REROLLS": func(c *Config, value string) error {
+		rerolls, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid number of rerolls %q", value)
+		}
+		c.GameSettings.TotalRerolls = rerolls
+		return nil
+	},
+}
+
+// Load reads the config file at path on top of the defaults and applies the
+// environment variables to the result. An empty path skips the config file.
+func Load(path string) (*Config, error) {
+	c := Default()
+
+	if path != "" {
+		configFile, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the config file: %w", err)
+		}
+		err = json.Unmarshal(configFile, c)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the config file %s: %w", path, err)
+		}
+	}
+
+	for name, override := range envOverrides {
+		value, exists := os.LookupEnv(name)
+		if !exists {
+			continue
+		}
+		err := override(c, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate checks the settings and normalizes the paths and the base url.
+func (c *Config) Validate() error {
+	_, err := log.ParseLevel(c.LogLevel)
+	if err != nil {
+		return fmt.Errorf("invalid logLevel %q", c.LogLevel)
+	}
+
+	c.StoragePath = withTrailingSlash(c.StoragePath)
+	c.WordsPath = withTrailingSlash(c.WordsPath)
+	err = checkWritable(c.StoragePath)
+	if err != nil {
+		return fmt.Errorf("storagePath %s is not writable: %w", c.StoragePath, err)
+	}
+
+	baseUrl, err := url.Parse(c.BaseUrl)
+	if err != nil {
+		return fmt.Errorf("invalid baseUrl %q: %w", c.BaseUrl, err)
+	}
+	if (baseUrl.Scheme != "http" && baseUrl.Scheme != "https") || baseUrl.Host == "" {
+		return fmt.Errorf("invalid baseUrl %q: must be an absolute http or https url", c.BaseUrl)
+	}
+	c.BaseUrl = strings.TrimSuffix(c.BaseUrl, "/")
+
+	_, _, err = net.SplitHostPort(c.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("invalid listenAddress %q: %w", c.ListenAddress, err)
+	}
+
+	for _, guild := range c.Bot.Guilds {
+		if _, err := strconv.ParseUint(guild, 10, 64); err != nil {
+			return fmt.Errorf("invalid guild id %q in bot.guilds", guild)
+		}
+	}
+
+	err = c.Twitch.validate()
+	if err != nil {
+		return err
+	}
+	c.Automation.normalize()
+	return c.GameSettings.validate()
+}
+
+// normalize lowercases the event names as events are matched ignoring the case.
+func (a *Automation) normalize() {
+	for kind, aliases := range a.Aliases {
+		normalized := make(map[string]string, len(aliases))
+		for event, field := range aliases {
+			normalized[strings.ToLower(strings.TrimSpace(event))] = field
+		}
+		a.Aliases[kind] = normalized
+	}
+}
+
+// validate checks the twitch settings and normalizes the channel names.
+func (t *TwitchSettings) validate() error {
+	if t.MaxBoards < 0 {
+		return fmt.Errorf("twitch.maxBoards must not be negative")
+	}
+	if len(t.Channels) == 0 {
+		return nil
+	}
+	if t.Nick == "" {
+		return fmt.Errorf("twitch.nick is required to join twitch channels")
+	}
+	_, _, err := net.SplitHostPort(t.Server)
+	if err != nil {
+		return fmt.Errorf("invalid twitch.server %q: %w", t.Server, err)
+	}
+
+	channels := make(map[string]string, len(t.Channels))
+	for channel, guild := range t.Channels {
+		if _, err := strconv.ParseUint(guild, 10, 64); err != nil {
+			return fmt.Errorf("invalid guild id %q for twitch channel %s", guild, channel)
+		}
+		channels[strings.ToLower(strings.TrimPrefix(channel, "#"))] = guild
+	}
+	t.Channels = channels
+	return nil
+}
+
+func (g GameSettings) validate() error {
+	if g.TotalRerolls < 0 {
+		return fmt.Errorf("totalRerolls must not be negative")
+	}
+	if g.DifficultyTolerance < 0 {
+		return fmt.Errorf("difficultyTolerance must not be negative")
+	}
+	for name, policy := range g.RerollPolicies {
+		if policy.Cost < 0 || policy.SwapCost < 0 || policy.CooldownSeconds < 0 || policy.RegenerateSeconds < 0 ||
+			policy.MaxRerolls < 0 || policy.LineBonus < 0 || policy.ExcludeRecent < 0 {
+			return fmt.Errorf("reroll policy %s has negative values", name)
+		}
+	}
+	if _, exists := g.RerollPolicies[g.DefaultRerollPolicy]; g.DefaultRerollPolicy != "" && !exists {
+		return fmt.Errorf("defaultRerollPolicy %s is not in rerollPolicies", g.DefaultRerollPolicy)
+	}
+	for _, points := range g.SeriesScoring.PlacementPoints {
+		if points < 0 {
+			return fmt.Errorf("seriesScoring.placementPoints must not be negative")
+		}
+	}
+	return nil
+}
 
-func init() {
+func withTrailingSlash(path string) string {
+	if path == "" || strings.HasSuffix(path, "/") {
+		return path
+	}
+	return path + "/"
+}
 
-	configFile, err := ioutil.ReadFile("config.json")
+// checkWritable creates the directory if needed and tries to write a file into it.
+func checkWritable(path string) error {
+	if path == "" {
+		return fmt.Errorf("no path set")
+	}
+	err := os.MkdirAll(path, 0755)
+	if err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(path, ".writecheck")
 	if err != nil {
-		log.WithError(err).Fatal("Failed to read config file")
-		return
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// Token returns the bot token from the environment variable or the token file.
+func (b BotSettings) Token() (string, error) {
+	return readToken("bot", b.TokenEnv, b.TokenFile)
+}
+
+// Token returns the oauth token of the twitch account from the environment variable or the token file.
+func (t TwitchSettings) Token() (string, error) {
+	return readToken("twitch", t.TokenEnv, t.TokenFile)
+}
+
+func readToken(setting, tokenEnv, tokenFile string) (string, error) {
+	if tokenEnv != "" {
+		if token := strings.TrimSpace(os.Getenv(tokenEnv)); token != "" {
+			return token, nil
+		}
+	}
+	if tokenFile == "" {
+		return "", fmt.Errorf("no %s token: set the %s environment variable or %s.tokenFile", setting, tokenEnv, setting)
 	}
 
-	Json = config{}
-	err = json.Unmarshal(configFile, &Json)
+	token, err := ioutil.ReadFile(tokenFile)
 	if err != nil {
-		log.WithError(err).Fatal("Failed to unmarshal config")
-		return
+		return "", fmt.Errorf("could not read the %s token: %w", setting, err)
+	}
+	if strings.TrimSpace(string(token)) == "" {
+		return "", fmt.Errorf("the token file %s is empty", tokenFile)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// Diff describes every setting that differs between the old and the new config.
+func Diff(old, new *Config) []string {
+	changes := make([]string, 0)
+	diffValues("", flatten(old), flatten(new), &changes)
+	return changes
+}
+
+// RequiresRestart reports the changed settings that only take effect after a restart.
+func RequiresRestart(old, new *Config) []string {
+	settings := make([]string, 0)
+	if old.StoragePath != new.StoragePath {
+		settings = append(settings, "storagePath")
+	}
+	if old.ListenAddress != new.ListenAddress {
+		settings = append(settings, "listenAddress")
+	}
+	if old.Bot.TokenEnv != new.Bot.TokenEnv || old.Bot.TokenFile != new.Bot.TokenFile ||
+		strings.Join(old.Bot.Guilds, ",") != strings.Join(new.Bot.Guilds, ",") {
+		settings = append(settings, "bot")
+	}
+	if fmt.Sprint(old.Twitch) != fmt.Sprint(new.Twitch) {
+		settings = append(settings, "twitch")
+	}
+	return settings
+}
+
+func flatten(c *Config) map[string]interface{} {
+	values := make(map[string]interface{})
+	content, _ := json.Marshal(c)
+	json.Unmarshal(content, &values)
+	return values
+}
+
+func diffValues(prefix string, old, new map[string]interface{}, changes *[]string) {
+	keys := make([]string, 0, len(old)+len(new))
+	for key := range old {
+		keys = append(keys, key)
+	}
+	for key := range new {
+		if _, exists := old[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		oldValue, newValue := old[key], new[key]
+		oldMap, oldIsMap := oldValue.(map[string]interface{})
+		newMap, newIsMap := newValue.(map[string]interface{})
+		if oldIsMap && newIsMap {
+			diffValues(prefix+key+".", oldMap, newMap, changes)
+			continue
+		}
+
+		oldJson, _ := json.Marshal(oldValue)
+		newJson, _ := json.Marshal(newValue)
+		if string(oldJson) != string(newJson) {
+			*changes = append(*changes, prefix+key+": "+string(oldJson)+" -> "+string(newJson))
+		}
 	}
 }
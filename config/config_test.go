@@ -0,0 +1,52 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadLayers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	err := ioutil.WriteFile(path, []byte(`{"storagePath": "`+dir+`", "logLevel": "debug", "gameSettings": {"totalRerolls": 3}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BINGO_TOTAL_REROLLS", "5")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LogLevel != "debug" || cfg.StoragePath != dir+"/" {
+		t.Errorf("config file not applied: %+v", cfg)
+	}
+	if cfg.GameSettings.TotalRerolls != 5 {
+		t.Errorf("expected rerolls from the environment, got %d", cfg.GameSettings.TotalRerolls)
+	}
+	if cfg.ListenAddress != ":8080" {
+		t.Errorf("expected the default listen address, got %q", cfg.ListenAddress)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	t.Setenv("BINGO_STORAGE_PATH", t.TempDir())
+	t.Setenv("BINGO_TOTAL_REROLLS", "-1")
+
+	_, err := Load("")
+	if err == nil {
+		t.Error("expected negative rerolls to be rejected")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := Default()
+	new := Default()
+	new.GameSettings.TotalRerolls = 4
+
+	changes := Diff(old, new)
+	if len(changes) != 1 || changes[0] != "gameSettings.totalRerolls: 2 -> 4" {
+		t.Errorf("unexpected changes %v", changes)
+	}
+}
@@ -0,0 +1,105 @@
+package httpserver
+
+import (
+	"Bingo/bingo"
+	"Bingo/bot"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IdempotencyHeader identifies a request so retries of it are not applied twice.
+const IdempotencyHeader = "Idempotency-Key"
+
+// automationLock serializes the automation requests so retries running at the same time are applied once.
+var automationLock sync.Mutex
+
+// automationRequest is the body of an automation request. Completed defaults to true.
+type automationRequest struct {
+	Event     string `json:"event"`
+	Completed *bool  `json:"completed"`
+	// Time is when the event happened, e.g. in a replayed match. It defaults to now and must not be in the future.
+	Time *time.Time `json:"time"`
+}
+
+// handleAutomationApi marks fields for scripts under POST /api/automation/{bingo}.
+// The request carries the api key of the bingo as bearer token and names an
+// event that is mapped to a field by the aliases of the config. Requests with an
+// Idempotency-Key header are applied once, retries get the first result again.
+func handleAutomationApi(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(resp, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	url := strings.Split(req.URL.Path, "/")
+	if len(url) < 4 {
+		http.NotFound(resp, req)
+		return
+	}
+	bin, exists := bingo.Bingos[url[3]]
+	if !exists {
+		http.NotFound(resp, req)
+		return
+	}
+	key := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if bin.ApiKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(bin.ApiKey)) != 1 {
+		http.Error(resp, "Wrong api key", http.StatusUnauthorized)
+		return
+	}
+
+	request := automationRequest{}
+	err := json.NewDecoder(http.MaxBytesReader(resp, req.Body, 4096)).Decode(&request)
+	if err != nil {
+		http.Error(resp, "Invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	completed := request.Completed == nil || *request.Completed
+	now := time.Now()
+	if request.Time != nil && request.Time.After(now) {
+		http.Error(resp, "The time must not be in the future", http.StatusBadRequest)
+		return
+	}
+	at := now
+	if request.Time != nil {
+		at = *request.Time
+	}
+
+	automationLock.Lock()
+	defer automationLock.Unlock()
+
+	idempotencyKey := req.Header.Get(IdempotencyHeader)
+	if idempotencyKey != "" {
+		if result, exists := bin.AutomationResultFor(idempotencyKey); exists {
+			resp.Header().Set("Idempotent-Replayed", "true")
+			writeJson(resp, result)
+			return
+		}
+	}
+
+	bin.Lock()
+	field, err := bin.ResolveEvent(settings().Automation.Aliases, request.Event)
+	bin.Unlock()
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	result := bingo.AutomationResult{
+		Event:     request.Event,
+		Field:     field,
+		Completed: completed,
+		Time:      now,
+	}
+	result.Changed = bot.SetFieldAt(bin, field, completed, at)
+	bin.Lock()
+	defer bin.Unlock()
+	if idempotencyKey != "" {
+		bin.RememberAutomationResult(idempotencyKey, result)
+		bin.Store(settings().StoragePath)
+	}
+	writeJson(resp, result)
+}
@@ -0,0 +1,74 @@
+package httpserver
+
+import (
+	"Bingo/bingo"
+	"Bingo/config"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func automationRequestFor(bin *bingo.Bingo, apiKey, idempotencyKey, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/automation/"+bin.Id, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	if idempotencyKey != "" {
+		req.Header.Set(IdempotencyHeader, idempotencyKey)
+	}
+	resp := httptest.NewRecorder()
+	handleAutomationApi(resp, req)
+	return resp
+}
+
+func TestAutomation(t *testing.T) {
+	cfg := config.Default()
+	cfg.WordsPath = "../bingos/"
+	cfg.StoragePath = t.TempDir() + "/"
+	cfg.Automation.Aliases = map[string]map[string]string{"valorant": {"clutch_1v3": "1v3 Clutch"}}
+	SetConfig(cfg)
+	bingo.Bingos = make(map[string]*bingo.Bingo)
+	bin, err := bingo.Create(cfg, "100", "300", "valorant", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bingo.AddBingo(bin)
+	if _, exists := bin.Completed["1v3 Clutch"]; !exists {
+		t.Skip("1v3 Clutch is not in the word list")
+	}
+
+	if resp := automationRequestFor(bin, "wrong", "", `{"event":"ace"}`); resp.Code != http.StatusUnauthorized {
+		t.Errorf("expected a wrong key to be rejected, got %d", resp.Code)
+	}
+	if resp := automationRequestFor(bin, bin.ApiKey, "", `{"event":"unknown"}`); resp.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected an unknown event to be rejected, got %d", resp.Code)
+	}
+
+	resp := automationRequestFor(bin, bin.ApiKey, "match-1-clutch", `{"event":"clutch_1v3"}`)
+	result := bingo.AutomationResult{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if resp.Code != http.StatusOK || result.Field != "1v3 Clutch" || !result.Changed || !bin.Completed["1v3 Clutch"] {
+		t.Fatalf("expected the field to be marked, got %d %+v", resp.Code, result)
+	}
+
+	// The host unmarks the field by hand, a retry of the request must not mark it again
+	bin.Completed["1v3 Clutch"] = false
+	resp = automationRequestFor(bin, bin.ApiKey, "match-1-clutch", `{"event":"clutch_1v3"}`)
+	if resp.Header().Get("Idempotent-Replayed") != "true" || bin.Completed["1v3 Clutch"] {
+		t.Errorf("expected the retry to be replayed without marking the field")
+	}
+
+	resp = automationRequestFor(bin, bin.ApiKey, "match-1-clutch-undo", `{"event":"1V3 clutch","completed":false}`)
+	json.NewDecoder(resp.Body).Decode(&result)
+	if result.Changed || result.Completed {
+		t.Errorf("expected unmarking an open field to change nothing, got %+v", result)
+	}
+
+	stored, err := bingo.Load(cfg.StoragePath, bin.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := stored.AutomationResultFor("match-1-clutch"); !exists {
+		t.Errorf("expected the idempotency key to be stored with the bingo")
+	}
+}
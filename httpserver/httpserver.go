@@ -1,37 +1,81 @@
 package httpserver
 
 import (
+	"Bingo/analytics"
 	"Bingo/bingo"
 	"Bingo/bot"
 	"Bingo/config"
+	"Bingo/render"
+	"Bingo/series"
+	"Bingo/stats"
 	"Bingo/webhub"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
 	"io/ioutil"
-	"math/rand"
 	"net/http"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
 var (
 	hub *webhub.Hub
+	// currentSettings is the config of the webserver, replaced when the config is reloaded.
+	currentSettings atomic.Pointer[config.Config]
 )
 
-func Listen() {
+func settings() *config.Config {
+	if cfg := currentSettings.Load(); cfg != nil {
+		return cfg
+	}
+	return config.Default()
+}
+
+// SetConfig applies a reloaded config.
+func SetConfig(cfg *config.Config) {
+	currentSettings.Store(cfg)
+}
+
+// Listen serves the web frontend on the configured address until the server fails.
+func Listen(cfg *config.Config) error {
+	SetConfig(cfg)
 	hub = webhub.NewHub()
 	go hub.Run()
+	bot.Broadcast = hub.Send
 
 	http.HandleFunc("/bingo/", handleBoard)
 	http.HandleFunc("/main/", handleMain)
 	http.HandleFunc("/completed/", handleCompleted)
 	http.HandleFunc("/reroll/", handleReroll)
+	http.HandleFunc("/swap/", handleSwap)
+	http.HandleFunc("/handicap/", handleHandicap)
+	http.HandleFunc("/host/", handleHost)
+	http.HandleFunc("/img/", handleImage)
+	http.HandleFunc("/overlay/", handleOverlay)
+	http.HandleFunc("/pdf/", handlePDF)
+	http.HandleFunc("/spectate/", handleSpectate)
+	http.HandleFunc("/api/spectate/", handleSpectateApi)
+	http.HandleFunc("/series/", handleSeries)
+	http.HandleFunc("/leaderboard/", handleLeaderboard)
+	http.HandleFunc("/api/stats/", handleStatsApi)
+	http.HandleFunc("/api/fields/", handleFieldsApi)
+	http.HandleFunc("/api/webhooks/", handleWebhooksApi)
+	http.HandleFunc("/api/automation/", handleAutomationApi)
+	http.HandleFunc("/report/", handleReport)
 	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
 		webhub.ServeWs(hub, w, r)
 	})
 	http.Handle("/", http.FileServer(http.Dir("frontend")))
 
-	http.ListenAndServe(":8080", nil)
+	return http.ListenAndServe(cfg.ListenAddress, nil)
 }
 
 func handleCompleted(resp http.ResponseWriter, req *http.Request) {
@@ -40,31 +84,16 @@ func handleCompleted(resp http.ResponseWriter, req *http.Request) {
 	bingolink := url[2]
 	word := strings.TrimSpace(url[3])
 
-	bingo := bingo.Bingos[bingolink]
-	if req.URL.Query().Get("pass") != bingo.Password {
+	bingo, exists := bingo.Bingos[bingolink]
+	if !exists {
+		http.NotFound(resp, req)
 		return
 	}
-
-	newValue := false
-
-	for _, field := range bingo.Words {
-		field = strings.TrimSpace(field)
-		if field == word {
-			newValue = !bingo.Completed[field]
-			bingo.Completed[field] = newValue
-			break
-		}
+	if req.URL.Query().Get("pass") != bingo.Password {
+		return
 	}
 
-	hub.Broadcast <- []byte(word + ";" + strconv.FormatBool(newValue))
-	bingo.Store(config.Json.StoragePath)
-	finished := bingo.CheckFinished()
-	if len(finished) > 0 {
-		err := bot.BingoFinished(bingo)
-		if err != nil {
-			log.WithError(err).Error("Failed to Finish bingo")
-		}
-	}
+	bot.ToggleField(bingo, word)
 }
 
 func handleReroll(resp http.ResponseWriter, req *http.Request) {
@@ -73,7 +102,8 @@ func handleReroll(resp http.ResponseWriter, req *http.Request) {
 	}
 
 	url := strings.Split(req.URL.Path, "/")
-	if len(url) < 4 {
+	if len(url) < 5 {
+		http.NotFound(resp, req)
 		return
 	}
 
@@ -82,55 +112,112 @@ func handleReroll(resp http.ResponseWriter, req *http.Request) {
 
 	submittedPass := req.URL.Query().Get("pass")
 	oldWord := req.URL.Query().Get("value")
+	index, err := strconv.Atoi(req.URL.Query().Get("index"))
+	if err != nil {
+		http.Error(resp, "Invalid index", http.StatusBadRequest)
+		return
+	}
 
 	bingo, exists := bingo.Bingos[bingolink]
 	if !exists {
+		http.NotFound(resp, req)
 		return
 	}
+	bingo.Lock()
+	defer bingo.Unlock()
 	board, exists := bingo.Boards[boardlink]
 	if !exists {
+		http.NotFound(resp, req)
 		return
 	}
 
 	if submittedPass != board.Password {
+		http.Error(resp, "Wrong password", http.StatusForbidden)
 		return
 	}
 
-	if board.Rerolls <= 0 {
+	newWord, err := bingo.Reroll(board, index, oldWord)
+	if err != nil {
+		writeRerollError(resp, err)
 		return
 	}
 
-	possibleWords := copyArrayFromMap(bingo.Completed)
-	for word, val := range bingo.Completed {
-		if val {
-			index := findIndex(possibleWords, word)
-			if index >= 0 {
-				possibleWords = remove(possibleWords, index)
-			}
-		}
+	hub.Send(bingo.Id, []byte("Reroll"))
+	bingo.Store(settings().StoragePath)
+	bot.RefreshBoards(bingo)
+	resp.Header().Add("content-type", "text/plain")
+	resp.Write([]byte(newWord + ";" + strconv.Itoa(board.Rerolls)))
+}
+
+func handleSwap(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		return
 	}
-	for _, word := range board.Content {
-		index := findIndex(possibleWords, word)
-		if index >= 0 {
-			possibleWords = remove(possibleWords, index)
-		}
+
+	url := strings.Split(req.URL.Path, "/")
+	if len(url) < 4 {
+		http.NotFound(resp, req)
+		return
 	}
 
-	if len(possibleWords) <= 0 {
+	bin, exists := bingo.Bingos[url[2]]
+	if !exists {
+		http.NotFound(resp, req)
+		return
+	}
+	bin.Lock()
+	defer bin.Unlock()
+	board, exists := bin.Boards[url[3]]
+	if !exists {
+		http.NotFound(resp, req)
+		return
+	}
+	if req.URL.Query().Get("pass") != board.Password {
+		http.Error(resp, "Wrong password", http.StatusForbidden)
 		return
 	}
 
-	newWord := possibleWords[rand.Intn(len(possibleWords))]
+	first, err := strconv.Atoi(req.URL.Query().Get("first"))
+	if err != nil {
+		http.Error(resp, "Invalid index", http.StatusBadRequest)
+		return
+	}
+	second, err := strconv.Atoi(req.URL.Query().Get("second"))
+	if err != nil {
+		http.Error(resp, "Invalid index", http.StatusBadRequest)
+		return
+	}
 
-	index := findIndex(board.Content, oldWord)
+	err = bin.Swap(board, first, second)
+	if err != nil {
+		writeRerollError(resp, err)
+		return
+	}
 
-	board.Content[index] = newWord
-	board.Rerolls -= 1
+	hub.Send(bin.Id, []byte("Reroll"))
+	bin.Store(settings().StoragePath)
+	bot.RefreshBoards(bin)
+}
 
-	hub.Broadcast <- []byte("Reroll")
-	bingo.Store(config.Json.StoragePath)
-	resp.Header().Add("content-type", "text/plain")
-	resp.Write([]byte(newWord + ";" + strconv.Itoa(board.Rerolls)))
+// writeRerollError maps a rejected reroll or swap to a 4xx response.
+func writeRerollError(resp http.ResponseWriter, err error) {
+	var rerollErr *bingo.RerollError
+	if !errors.As(err, &rerollErr) {
+		log.WithError(err).Error("Reroll failed")
+		http.Error(resp, "Reroll failed", http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusBadRequest
+	switch rerollErr.Kind {
+	case bingo.RerollForbidden:
+		status = http.StatusForbidden
+	case bingo.RerollConflict:
+		status = http.StatusConflict
+	case bingo.RerollTooEarly:
+		status = http.StatusTooManyRequests
+	}
+	http.Error(resp, rerollErr.Message, status)
 }
 
 func handleMain(resp http.ResponseWriter, req *http.Request) {
@@ -145,6 +232,12 @@ func handleMain(resp http.ResponseWriter, req *http.Request) {
 	if !exists {
 		return
 	}
+	if req.URL.Query().Get("pass") != bingo.Password {
+		http.Error(resp, "Wrong password", http.StatusForbidden)
+		return
+	}
+	bingo.Lock()
+	defer bingo.Unlock()
 
 	body := ""
 	for _, field := range bingo.Words {
@@ -157,16 +250,325 @@ func handleMain(resp http.ResponseWriter, req *http.Request) {
 		}
 	}
 
+	players := ""
+	for _, board := range bingo.Boards {
+		players += `<div class="player" id="handicap/` + board.Id + `">` +
+			`<span class="playername">` + board.UserName + `</span>` +
+			`<label>Extra rerolls <input type="number" min="0" name="rerolls" value="` + strconv.Itoa(board.Handicap.ExtraRerolls) + `"></label>` +
+			`<label>Free cells <input type="number" min="0" name="free" value="` + strconv.Itoa(board.Handicap.FreeCells) + `"></label>` +
+			`<label>Difficulty <select name="difficulty">` + difficultyOptions(board.Handicap.Difficulty) + `</select></label>` +
+			`<button onclick="setHandicap('` + board.Id + `')">Set handicap</button>` +
+			`<button onclick="hostAction('regenerate', {board: '` + board.Id + `'})">New board</button>` +
+			`<button onclick="hostAction('remove', {board: '` + board.Id + `'})">Remove</button>` +
+			`</div>`
+	}
+
 	htmlTemplate, err := ioutil.ReadFile("frontend/index.html")
 	if err != nil {
 		return
 	}
 
+	spectatorLink := "/spectate/" + bingo.Id
+	overlayLink := "/overlay/" + bingo.Id
+	if bingo.SpectatorKey != "" {
+		spectatorLink += "?key=" + bingo.SpectatorKey
+		overlayLink += "?key=" + bingo.SpectatorKey
+	}
+
 	html := strings.ReplaceAll(string(htmlTemplate), "{{body}}", body)
+	html = strings.ReplaceAll(html, "{{players}}", players)
+	html = strings.ReplaceAll(html, "{{spectatorlink}}", spectatorLink)
+	html = strings.ReplaceAll(html, "{{overlaylink}}", overlayLink)
 
 	resp.Write([]byte(html))
 }
 
+func describeRerollState(state bingo.RerollState) string {
+	text := ""
+	if state.Locked {
+		text += "Rerolls are locked while the game is running. "
+	}
+	if state.Cost > 1 {
+		text += "A reroll costs " + strconv.Itoa(state.Cost) + ". "
+	}
+	if state.SwapAvailable {
+		text += "Swapping two cells costs " + strconv.Itoa(state.SwapCost) + ". "
+	}
+	if state.Cooldown > 0 {
+		text += "Cooldown: " + state.Cooldown.Round(time.Second).String() + ". "
+	}
+	if state.NextRegen > 0 {
+		text += "Next reroll in " + state.NextRegen.Round(time.Second).String() + "."
+	}
+	return text
+}
+
+func difficultyOptions(selected int) string {
+	options := `<option value="">default</option>`
+	for _, level := range []string{"easy", "normal", "hard"} {
+		value := bingo.DifficultyLevels[level]
+		if value == selected {
+			options += `<option value="` + level + `" selected>` + level + `</option>`
+		} else {
+			options += `<option value="` + level + `">` + level + `</option>`
+		}
+	}
+	return options
+}
+
+func handleHandicap(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		return
+	}
+
+	url := strings.Split(req.URL.Path, "/")
+	if len(url) < 4 {
+		http.NotFound(resp, req)
+		return
+	}
+
+	bin, exists := bingo.Bingos[url[2]]
+	if !exists {
+		http.NotFound(resp, req)
+		return
+	}
+	bin.Lock()
+	defer bin.Unlock()
+	if req.URL.Query().Get("pass") != bin.Password {
+		http.Error(resp, "Wrong password", http.StatusForbidden)
+		return
+	}
+	board, exists := bin.Boards[url[3]]
+	if !exists {
+		http.NotFound(resp, req)
+		return
+	}
+
+	query := req.URL.Query()
+	handicap := bingo.Handicap{}
+	var err error
+	if query.Get("rerolls") != "" {
+		handicap.ExtraRerolls, err = strconv.Atoi(query.Get("rerolls"))
+		if err != nil {
+			http.Error(resp, "Invalid rerolls", http.StatusBadRequest)
+			return
+		}
+	}
+	if query.Get("free") != "" {
+		handicap.FreeCells, err = strconv.Atoi(query.Get("free"))
+		if err != nil {
+			http.Error(resp, "Invalid free cells", http.StatusBadRequest)
+			return
+		}
+	}
+	handicap.Difficulty, err = bingo.ParseDifficulty(query.Get("difficulty"))
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = bin.SetHandicap(board, handicap)
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	bin.UpdateWinners()
+	hub.Send(bin.Id, []byte("Handicap"))
+	bin.Store(settings().StoragePath)
+	bot.RefreshBoards(bin)
+}
+
+// handleHost executes the board editing tools of the management plane.
+func handleHost(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		return
+	}
+
+	url := strings.Split(req.URL.Path, "/")
+	if len(url) < 4 {
+		http.NotFound(resp, req)
+		return
+	}
+
+	bin, exists := bingo.Bingos[url[2]]
+	if !exists {
+		http.NotFound(resp, req)
+		return
+	}
+	bin.Lock()
+	defer bin.Unlock()
+	query := req.URL.Query()
+	if query.Get("pass") != bin.Password {
+		http.Error(resp, "Wrong password", http.StatusForbidden)
+		return
+	}
+
+	var err error
+	switch url[3] {
+	case "replace":
+		err = bin.ReplaceWord(strings.TrimSpace(query.Get("old")), strings.TrimSpace(query.Get("new")))
+	case "regenerate":
+		board, exists := bin.Boards[query.Get("board")]
+		if !exists {
+			http.NotFound(resp, req)
+			return
+		}
+		bin.RegenerateBoard(board)
+	case "remove":
+		err = bin.RemoveBoard(query.Get("board"))
+	case "add":
+		words := make([]string, 0)
+		for _, word := range strings.Split(query.Get("words"), "\n") {
+			words = append(words, strings.TrimSpace(word))
+		}
+		if len(bin.AddWords(words)) == 0 {
+			err = errors.New("no new fields")
+		}
+	default:
+		http.NotFound(resp, req)
+		return
+	}
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	bin.UpdateWinners()
+	hub.Send(bin.Id, []byte("Host"))
+	bin.Store(settings().StoragePath)
+	bot.RefreshBoards(bin)
+}
+
+// handlePDF serves printable cards of all boards under /pdf/{bingo}. With the
+// cards parameter it creates that many anonymous boards and prints only those.
+func handlePDF(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		return
+	}
+
+	url := strings.Split(req.URL.Path, "/")
+	if len(url) < 3 {
+		http.NotFound(resp, req)
+		return
+	}
+
+	bin, exists := bingo.Bingos[url[2]]
+	if !exists {
+		http.NotFound(resp, req)
+		return
+	}
+	bin.Lock()
+	defer bin.Unlock()
+	query := req.URL.Query()
+	if query.Get("pass") != bin.Password {
+		http.Error(resp, "Wrong password", http.StatusForbidden)
+		return
+	}
+
+	perPage := 1
+	if query.Get("perpage") != "" {
+		var err error
+		perPage, err = strconv.Atoi(query.Get("perpage"))
+		if err != nil || perPage <= 0 || perPage > 16 {
+			http.Error(resp, "Invalid number of cards per page", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var boards []*bingo.BingoBoard
+	if query.Get("cards") != "" {
+		count, err := strconv.Atoi(query.Get("cards"))
+		if err != nil || count > bingo.MaxAnonymousCards {
+			http.Error(resp, "Invalid number of cards", http.StatusBadRequest)
+			return
+		}
+		boards, err = bin.CreateAnonymousBoards(count)
+		if err != nil {
+			http.Error(resp, err.Error(), http.StatusBadRequest)
+			return
+		}
+		bin.Store(settings().StoragePath)
+		hub.Send(bin.Id, []byte("Host"))
+	} else {
+		boards = make([]*bingo.BingoBoard, 0, len(bin.Boards))
+		for _, board := range bin.Boards {
+			boards = append(boards, board)
+		}
+		sort.Slice(boards, func(i, j int) bool { return boards[i].UserName < boards[j].UserName })
+	}
+
+	var buf bytes.Buffer
+	err := render.PDF(&buf, bin, boards, perPage)
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/pdf")
+	resp.Header().Set("Content-Disposition", `attachment; filename="bingo-`+bin.Id+`.pdf"`)
+	buf.WriteTo(resp)
+}
+
+// handleImage serves a board as png or svg under /img/{bingo}/{board}.png or .svg.
+// Protected bingos need the spectator key or the password of the board.
+func handleImage(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		return
+	}
+
+	url := strings.Split(req.URL.Path, "/")
+	if len(url) < 4 {
+		http.NotFound(resp, req)
+		return
+	}
+
+	boardlink := url[3]
+	extension := filepath.Ext(boardlink)
+	boardlink = strings.TrimSuffix(boardlink, extension)
+
+	bin, err := bingo.Get(settings().StoragePath, url[2])
+	if err != nil {
+		http.NotFound(resp, req)
+		return
+	}
+	bin.Lock()
+	defer bin.Unlock()
+	board, exists := bin.Boards[boardlink]
+	if !exists {
+		http.NotFound(resp, req)
+		return
+	}
+	// The board messages show the image with the password of the board instead of the spectator key
+	if !canSpectate(req, bin) && req.URL.Query().Get("pass") != board.Password {
+		http.Error(resp, "Wrong spectator key", http.StatusForbidden)
+		return
+	}
+
+	etag := render.ETag(bin, board)
+	resp.Header().Set("ETag", etag)
+	resp.Header().Set("Cache-Control", "public, max-age=5, must-revalidate")
+	if req.Header.Get("If-None-Match") == etag {
+		resp.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	switch extension {
+	case ".png":
+		resp.Header().Set("content-type", "image/png")
+		err = render.PNG(resp, bin, board)
+	case ".svg":
+		resp.Header().Set("content-type", "image/svg+xml")
+		err = render.SVG(resp, bin, board)
+	default:
+		http.NotFound(resp, req)
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to render board")
+	}
+}
+
 func handleBoard(resp http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodGet {
 		return
@@ -180,18 +582,22 @@ func handleBoard(resp http.ResponseWriter, req *http.Request) {
 	if !exists {
 		return
 	}
+	bingo.Lock()
+	defer bingo.Unlock()
 	board, exists := bingo.Boards[boardlink]
 	if !exists {
 		return
 	}
 
+	rerollState := bingo.RerollState(board)
+
 	body := ""
-	for _, field := range board.Content {
+	for index, field := range board.Content {
 		field = strings.TrimSpace(field)
-		if bingo.Completed[field] {
-			body += `<div class="grid-item-completed" id="` + field + `">` + field + "</div>"
+		if bingo.IsDone(field) {
+			body += `<div class="grid-item-completed" id="` + field + `" data-index="` + strconv.Itoa(index) + `">` + field + "</div>"
 		} else {
-			body += `<div class="grid-item" id="` + field + `" onclick="reroll(this)">` + field + "</div>"
+			body += `<div class="grid-item" id="` + field + `" data-index="` + strconv.Itoa(index) + `" onclick="reroll(this)">` + field + "</div>"
 		}
 	}
 
@@ -204,12 +610,16 @@ func handleBoard(resp http.ResponseWriter, req *http.Request) {
 		if otherBoard.Id == board.Id {
 			continue
 		}
-		playernames += `<p class="playername">` + otherBoard.UserName + `</p>`
+		handicap := otherBoard.Handicap.String()
+		if handicap != "" {
+			handicap = `<br><span class="handicap">` + handicap + `</span>`
+		}
+		playernames += `<p class="playername">` + otherBoard.UserName + handicap + `</p>`
 
 		miniboards += `<div class="grid-container-mini">`
 		for _, field := range otherBoard.Content {
 			field = strings.TrimSpace(field)
-			if bingo.Completed[field] {
+			if bingo.IsDone(field) {
 				miniboards += `<div class="grid-item-completed-mini" id="` + strconv.Itoa(count) + "/" + field + `">` + field + "</div>"
 			} else {
 				miniboards += `<div class="grid-item-mini" id="` + strconv.Itoa(count) + "/" + field + `">` + field + "</div>"
@@ -229,39 +639,423 @@ func handleBoard(resp http.ResponseWriter, req *http.Request) {
 	html = strings.ReplaceAll(html, "{{miniboards}}", miniboards)
 	html = strings.ReplaceAll(html, "{{playernames}}", playernames)
 	html = strings.ReplaceAll(html, "{{rerolls}}", strconv.Itoa(board.Rerolls))
+	html = strings.ReplaceAll(html, "{{rerollstate}}", describeRerollState(rerollState))
+	html = strings.ReplaceAll(html, "{{swapcost}}", strconv.Itoa(rerollState.SwapCost))
+	html = strings.ReplaceAll(html, "{{handicap}}", board.Handicap.String())
 
 	resp.Write([]byte(html))
 }
 
-func findIndex(array []string, val string) int {
-	for i, s := range array {
-		if val == s {
-			return i
+// handleOverlay renders one or all boards of a bingo for stream overlays under
+// /overlay/{bingo}/{board}. The page has a transparent background and can be
+// adjusted with the query parameters layout (grid, row, column),
+// theme (dark, light, neon), scale and names (false hides the player names).
+// Protected bingos need the spectator key as the key parameter.
+func handleOverlay(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		return
+	}
+
+	url := strings.Split(req.URL.Path, "/")
+	if len(url) < 3 {
+		http.NotFound(resp, req)
+		return
+	}
+
+	bin := spectatedBingo(resp, req, url[2])
+	if bin == nil {
+		return
+	}
+	bin.Lock()
+	defer bin.Unlock()
+
+	boards := make([]*bingo.BingoBoard, 0, len(bin.Boards))
+	if len(url) > 3 && url[3] != "" {
+		board, exists := bin.Boards[url[3]]
+		if !exists {
+			http.NotFound(resp, req)
+			return
+		}
+		boards = append(boards, board)
+	} else {
+		for _, board := range bin.Boards {
+			boards = append(boards, board)
+		}
+		sort.Slice(boards, func(i, j int) bool { return boards[i].UserName < boards[j].UserName })
+	}
+
+	query := req.URL.Query()
+	layout := query.Get("layout")
+	if layout != "row" && layout != "column" {
+		layout = "grid"
+	}
+	theme := query.Get("theme")
+	if theme != "light" && theme != "neon" {
+		theme = "dark"
+	}
+	scale, err := strconv.ParseFloat(query.Get("scale"), 64)
+	if err != nil || scale <= 0 || scale > 5 {
+		scale = 1
+	}
+	showNames := query.Get("names") != "false"
+
+	body := ""
+	for _, board := range boards {
+		body += `<div class="overlay-board">`
+		if showNames {
+			body += `<div class="overlay-name">` + html.EscapeString(board.UserName) + `</div>`
+		}
+		body += `<div class="overlay-grid" style="grid-template-columns: repeat(` + strconv.Itoa(boardColumns(board)) + `, 1fr)">`
+		for _, field := range board.Content {
+			class := "overlay-cell"
+			if bin.IsDone(field) {
+				class += " overlay-cell-completed"
+			}
+			body += `<div class="` + class + `" data-field="` + html.EscapeString(field) + `">` + html.EscapeString(field) + `</div>`
+		}
+		body += `</div></div>`
+	}
+
+	htmlTemplate, err := ioutil.ReadFile("frontend/overlay.html")
+	if err != nil {
+		return
+	}
+
+	page := strings.ReplaceAll(string(htmlTemplate), "{{boards}}", body)
+	page = strings.ReplaceAll(page, "{{layout}}", layout)
+	page = strings.ReplaceAll(page, "{{theme}}", theme)
+	page = strings.ReplaceAll(page, "{{scale}}", strconv.FormatFloat(scale, 'f', -1, 64))
+
+	resp.Write([]byte(page))
+}
+
+// boardColumns returns the number of columns of a square board.
+func boardColumns(board *bingo.BingoBoard) int {
+	columns := 1
+	for columns*columns < len(board.Content) {
+		columns++
+	}
+	return columns
+}
+
+type spectatorView struct {
+	Id      string               `json:"id"`
+	Kind    string               `json:"kind"`
+	Boards  []spectatorBoard     `json:"boards"`
+	Winners []spectatorWinner    `json:"winners"`
+	Events  []spectatorEventView `json:"events"`
+}
+
+type spectatorBoard struct {
+	UserName  string          `json:"username"`
+	Completed int             `json:"completed"`
+	Size      int             `json:"size"`
+	OneAway   bool            `json:"oneAway"`
+	Placement int             `json:"placement"`
+	Handicap  string          `json:"handicap"`
+	Cells     []spectatorCell `json:"cells"`
+}
+
+type spectatorCell struct {
+	Field   string `json:"field"`
+	Done    bool   `json:"done"`
+	Winning bool   `json:"winning"`
+}
+
+type spectatorWinner struct {
+	UserName  string    `json:"username"`
+	Placement int       `json:"placement"`
+	Time      time.Time `json:"time"`
+}
+
+type spectatorEventView struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
+// spectatedBingo returns the bingo of a spectator request if the spectator key matches.
+func spectatedBingo(resp http.ResponseWriter, req *http.Request, bingolink string) *bingo.Bingo {
+	bin, exists := bingo.Bingos[bingolink]
+	if !exists {
+		http.NotFound(resp, req)
+		return nil
+	}
+	if !canSpectate(req, bin) {
+		http.Error(resp, "Wrong spectator key", http.StatusForbidden)
+		return nil
+	}
+	return bin
+}
+
+// canSpectate reports whether the request carries the spectator key of the bingo if the host protected it.
+func canSpectate(req *http.Request, bin *bingo.Bingo) bool {
+	return bin.SpectatorKey == "" || req.URL.Query().Get("key") == bin.SpectatorKey
+}
+
+func handleSpectate(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		return
+	}
+
+	url := strings.Split(req.URL.Path, "/")
+	if len(url) < 3 {
+		http.NotFound(resp, req)
+		return
+	}
+	if spectatedBingo(resp, req, url[2]) == nil {
+		return
+	}
+
+	http.ServeFile(resp, req, "frontend/spectate.html")
+}
+
+func handleSpectateApi(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		return
+	}
+
+	url := strings.Split(req.URL.Path, "/")
+	if len(url) < 4 {
+		http.NotFound(resp, req)
+		return
+	}
+	bin := spectatedBingo(resp, req, url[3])
+	if bin == nil {
+		return
+	}
+	bin.Lock()
+	defer bin.Unlock()
+
+	view := spectatorView{
+		Id:      bin.Id,
+		Kind:    bin.Kind,
+		Boards:  make([]spectatorBoard, 0, len(bin.Boards)),
+		Winners: make([]spectatorWinner, 0, len(bin.Winners)),
+		Events:  make([]spectatorEventView, 0),
+	}
+
+	for _, board := range bin.Boards {
+		winning := make(map[int]bool)
+		for _, line := range bin.CompletedLines(board) {
+			for _, index := range line {
+				winning[index] = true
+			}
+		}
+
+		spectated := spectatorBoard{
+			UserName:  board.UserName,
+			Completed: bin.CountCompleted(board),
+			Size:      len(board.Content),
+			OneAway:   bin.OneAway(board),
+			Placement: bin.Placement(board.Id),
+			Handicap:  board.Handicap.String(),
+			Cells:     make([]spectatorCell, 0, len(board.Content)),
+		}
+		for index, field := range board.Content {
+			spectated.Cells = append(spectated.Cells, spectatorCell{
+				Field:   field,
+				Done:    bin.IsDone(field),
+				Winning: winning[index],
+			})
+		}
+		view.Boards = append(view.Boards, spectated)
+	}
+	sort.Slice(view.Boards, func(i, j int) bool {
+		if view.Boards[i].Completed != view.Boards[j].Completed {
+			return view.Boards[i].Completed > view.Boards[j].Completed
+		}
+		return view.Boards[i].UserName < view.Boards[j].UserName
+	})
+
+	for _, winner := range bin.Winners {
+		board, exists := bin.Boards[winner.BoardId]
+		if !exists {
+			continue
 		}
+		view.Winners = append(view.Winners, spectatorWinner{
+			UserName:  board.UserName,
+			Placement: bin.Placement(winner.BoardId),
+			Time:      winner.Time,
+		})
 	}
-	return -1
+
+	for _, event := range bin.RecentEvents(50) {
+		view.Events = append(view.Events, spectatorEventView{Time: event.Time, Text: event.String()})
+	}
+
+	resp.Header().Add("content-type", "application/json")
+	json.NewEncoder(resp).Encode(view)
+}
+
+func handleSeries(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		return
+	}
+
+	url := strings.Split(req.URL.Path, "/")
+	if len(url) < 3 {
+		return
+	}
+	serieslink := url[2]
+
+	ser, exists := series.AllSeries[serieslink]
+	if !exists {
+		http.NotFound(resp, req)
+		return
+	}
+
+	standings, err := ser.Standings(settings().StoragePath)
+	if err != nil {
+		log.WithError(err).Error("Failed to compute series standings")
+		http.Error(resp, "Failed to compute standings", http.StatusInternalServerError)
+		return
+	}
+
+	body := ""
+	for i, standing := range standings {
+		body += "<tr><td>" + strconv.Itoa(i+1) + "</td>" +
+			"<td>" + html.EscapeString(standing.UserName) + "</td>" +
+			"<td>" + strconv.Itoa(standing.Points) + "</td>" +
+			"<td>" + strconv.Itoa(standing.Games) + "</td>" +
+			"<td>" + strconv.Itoa(standing.Wins) + "</td>" +
+			"<td>" + strconv.Itoa(standing.Lines) + "</td>" +
+			"<td>" + strconv.Itoa(standing.Cells) + "</td></tr>"
+	}
+
+	status := "running"
+	if ser.Closed {
+		status = "closed"
+	}
+
+	htmlTemplate, err := ioutil.ReadFile("frontend/series.html")
+	if err != nil {
+		return
+	}
+
+	page := strings.ReplaceAll(string(htmlTemplate), "{{name}}", html.EscapeString(ser.Name))
+	page = strings.ReplaceAll(page, "{{status}}", status)
+	page = strings.ReplaceAll(page, "{{games}}", strconv.Itoa(len(ser.Bingos)))
+	page = strings.ReplaceAll(page, "{{standings}}", body)
+
+	resp.Write([]byte(page))
 }
 
-// func copyArray(array []string) []string {
-// 	newArray := make([]string, len(array))
-// 	for i, s := range array {
-// 		newArray[i] = s
-// 	}
-// 	return newArray
-// }
-
-func copyArrayFromMap(oldMap map[string]bool) []string {
-	newArray := make([]string, len(oldMap))
-	i := 0
-	for key := range oldMap {
-		newArray[i] = key
-		i++
-	}
-	return newArray
+func handleStatsApi(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		return
+	}
+
+	url := strings.Split(req.URL.Path, "/")
+	if len(url) < 4 || url[3] == "" {
+		http.NotFound(resp, req)
+		return
+	}
+	guildId := url[3]
+
+	var result interface{}
+	var err error
+	if len(url) > 4 && url[4] != "" {
+		result, err = stats.ForPlayer(settings().StoragePath, guildId, url[4])
+	} else {
+		result, err = stats.Leaderboard(settings().StoragePath, guildId)
+	}
+	if err == stats.ErrNoStats {
+		http.Error(resp, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to collect stats")
+		http.Error(resp, "Failed to collect stats", http.StatusInternalServerError)
+		return
+	}
+
+	resp.Header().Add("content-type", "application/json")
+	json.NewEncoder(resp).Encode(result)
 }
 
-func remove(s []string, i int) []string {
-	s[i] = s[len(s)-1]
-	// We do not need to put s[i] at the end, as it will be discarded anyway
-	return s[:len(s)-1]
+func handleFieldsApi(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		return
+	}
+
+	url := strings.Split(req.URL.Path, "/")
+	if len(url) < 4 || url[3] == "" {
+		http.NotFound(resp, req)
+		return
+	}
+
+	fields, err := analytics.ForKind(settings().StoragePath, url[3])
+	if err != nil {
+		log.WithError(err).Error("Failed to collect field stats")
+		http.Error(resp, "Failed to collect field stats", http.StatusInternalServerError)
+		return
+	}
+
+	resp.Header().Add("content-type", "application/json")
+	json.NewEncoder(resp).Encode(fields)
+}
+
+func handleReport(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		return
+	}
+
+	url := strings.Split(req.URL.Path, "/")
+	if len(url) < 3 || url[2] == "" {
+		http.NotFound(resp, req)
+		return
+	}
+
+	fields, err := analytics.ForKind(settings().StoragePath, url[2])
+	if err != nil {
+		log.WithError(err).Error("Failed to collect field stats")
+		http.Error(resp, "Failed to collect field stats", http.StatusInternalServerError)
+		return
+	}
+
+	resp.Header().Add("content-type", "text/plain; charset=utf-8")
+	analytics.WriteReport(resp, url[2], fields)
+}
+
+func handleLeaderboard(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		return
+	}
+
+	url := strings.Split(req.URL.Path, "/")
+	if len(url) < 3 || url[2] == "" {
+		http.NotFound(resp, req)
+		return
+	}
+
+	leaderboard, err := stats.Leaderboard(settings().StoragePath, url[2])
+	if err != nil {
+		log.WithError(err).Error("Failed to collect stats")
+		http.Error(resp, "Failed to collect stats", http.StatusInternalServerError)
+		return
+	}
+
+	body := ""
+	for i, player := range leaderboard {
+		fastest := "-"
+		if player.FastestBingo > 0 {
+			fastest = (time.Duration(player.FastestBingo) * time.Second).String()
+		}
+		body += "<tr><td>" + strconv.Itoa(i+1) + "</td>" +
+			"<td>" + html.EscapeString(player.UserName) + "</td>" +
+			"<td>" + strconv.Itoa(player.Games) + "</td>" +
+			"<td>" + strconv.Itoa(player.Wins) + "</td>" +
+			"<td>" + fmt.Sprintf("%.1f", player.AverageCells) + "</td>" +
+			"<td>" + strconv.Itoa(player.RerollsUsed) + "</td>" +
+			"<td>" + fastest + "</td></tr>"
+	}
+
+	htmlTemplate, err := ioutil.ReadFile("frontend/leaderboard.html")
+	if err != nil {
+		return
+	}
+
+	page := strings.ReplaceAll(string(htmlTemplate), "{{leaderboard}}", body)
+
+	resp.Write([]byte(page))
 }
@@ -0,0 +1,157 @@
+package httpserver
+
+import (
+	"Bingo/bingo"
+	"Bingo/bot"
+	"Bingo/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRerollRequests(t *testing.T) {
+	bingo.Bingos = make(map[string]*bingo.Bingo)
+	bin := &bingo.Bingo{
+		Id:        "bingo1",
+		Completed: map[string]bool{"a": true, "b": false, "c": false, "d": false, "e": false},
+		Boards:    make(map[string]*bingo.BingoBoard),
+	}
+	bin.Boards["board1"] = &bingo.BingoBoard{Id: "board1", Password: "secret", Rerolls: 1, Content: []string{"a", "b", "c", "d"}}
+	bingo.AddBingo(bin)
+
+	tests := []struct {
+		path     string
+		expected int
+	}{
+		{"/reroll/", http.StatusNotFound},
+		{"/reroll/bingo", http.StatusNotFound},
+		{"/reroll/bingo/bingo1", http.StatusNotFound},
+		{"/reroll/bingo/bingo1/board1", http.StatusBadRequest},
+		{"/reroll/bingo/bingo1/board1?index=x", http.StatusBadRequest},
+		{"/reroll/bingo/unknown/board1?index=0", http.StatusNotFound},
+		{"/reroll/bingo/bingo1/unknown?index=0", http.StatusNotFound},
+		{"/reroll/bingo/bingo1/board1?index=1&pass=wrong", http.StatusForbidden},
+		{"/reroll/bingo/bingo1/board1?index=4&pass=secret", http.StatusBadRequest},
+		{"/reroll/bingo/bingo1/board1?index=-1&pass=secret", http.StatusBadRequest},
+		{"/reroll/bingo/bingo1/board1?index=1&value=c&pass=secret", http.StatusConflict},
+		{"/reroll/bingo/bingo1/board1?index=0&value=a&pass=secret", http.StatusConflict},
+	}
+	for _, test := range tests {
+		resp := httptest.NewRecorder()
+		handleReroll(resp, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if resp.Code != test.expected {
+			t.Errorf("expected %d for %s, got %d", test.expected, test.path, resp.Code)
+		}
+	}
+
+	for _, path := range []string{"/swap/", "/swap/bingo1", "/swap/bingo1/board1?first=0&pass=secret"} {
+		resp := httptest.NewRecorder()
+		handleSwap(resp, httptest.NewRequest(http.MethodGet, path, nil))
+		if resp.Code != http.StatusNotFound && resp.Code != http.StatusBadRequest {
+			t.Errorf("expected 404 or 400 for %s, got %d", path, resp.Code)
+		}
+	}
+}
+
+func TestPDFCardLimit(t *testing.T) {
+	bingo.Bingos = make(map[string]*bingo.Bingo)
+	bin := &bingo.Bingo{Id: "bingo1", Password: "secret", Boards: make(map[string]*bingo.BingoBoard)}
+	bingo.AddBingo(bin)
+
+	for _, cards := range []string{"65", "1000", "x"} {
+		resp := httptest.NewRecorder()
+		handlePDF(resp, httptest.NewRequest(http.MethodGet, "/pdf/bingo1?pass=secret&cards="+cards, nil))
+		if resp.Code != http.StatusBadRequest || len(bin.Boards) != 0 {
+			t.Errorf("expected %s cards to be rejected, got %d", cards, resp.Code)
+		}
+	}
+}
+
+func TestAutomationTime(t *testing.T) {
+	cfg := config.Default()
+	cfg.StoragePath = t.TempDir() + "/"
+	SetConfig(cfg)
+	bot.SetConfig(cfg)
+	bingo.Bingos = make(map[string]*bingo.Bingo)
+	bin := &bingo.Bingo{
+		Id:        "bingo1",
+		ApiKey:    "key",
+		Completed: map[string]bool{"Ace": false, "Thrifty": false},
+		Boards:    make(map[string]*bingo.BingoBoard),
+	}
+	bingo.AddBingo(bin)
+
+	post := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/automation/bingo1", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer key")
+		resp := httptest.NewRecorder()
+		handleAutomationApi(resp, req)
+		return resp.Code
+	}
+
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	if code := post(`{"event": "Thrifty", "time": "` + future + `"}`); code != http.StatusBadRequest || bin.Completed["Thrifty"] {
+		t.Errorf("expected a time in the future to be rejected, got %d", code)
+	}
+
+	if code := post(`{"event": "Ace", "time": "2024-05-01T20:00:30Z"}`); code != http.StatusOK {
+		t.Fatalf("expected the field to be marked, got %d", code)
+	}
+	happened := time.Date(2024, 5, 1, 20, 0, 30, 0, time.UTC)
+	event := bin.Events[len(bin.Events)-1]
+	if !bin.CompletedAt["Ace"].Equal(happened) || event.Type != bingo.EventFieldCompleted || !event.Time.Equal(happened) {
+		t.Errorf("expected the completion and its event at %s, got %s and %s", happened, bin.CompletedAt["Ace"], event.Time)
+	}
+}
+
+func TestMainNeedsPassword(t *testing.T) {
+	bingo.Bingos = make(map[string]*bingo.Bingo)
+	bin := &bingo.Bingo{Id: "bingo1", Password: "secret", Boards: make(map[string]*bingo.BingoBoard)}
+	bingo.AddBingo(bin)
+
+	for _, path := range []string{"/main/bingo1/", "/main/bingo1/?pass=wrong"} {
+		resp := httptest.NewRecorder()
+		handleMain(resp, httptest.NewRequest(http.MethodGet, path, nil))
+		if resp.Code != http.StatusForbidden {
+			t.Errorf("expected 403 for %s, got %d", path, resp.Code)
+		}
+	}
+
+	resp := httptest.NewRecorder()
+	handleMain(resp, httptest.NewRequest(http.MethodGet, "/main/bingo1/?pass=secret", nil))
+	if resp.Code != http.StatusOK || bin.ApiKey != "" {
+		t.Errorf("expected the page without an automation key, got %d", resp.Code)
+	}
+}
+
+func TestSpectatorKey(t *testing.T) {
+	cfg := config.Default()
+	cfg.StoragePath = t.TempDir() + "/"
+	SetConfig(cfg)
+	bingo.Bingos = make(map[string]*bingo.Bingo)
+	bin := &bingo.Bingo{Id: "bingo1", SpectatorKey: "watch", Boards: make(map[string]*bingo.BingoBoard)}
+	bin.Boards["board1"] = &bingo.BingoBoard{Id: "board1", Password: "secret", Content: []string{"a", "b", "c", "d"}}
+	bingo.AddBingo(bin)
+
+	tests := []struct {
+		handler  http.HandlerFunc
+		path     string
+		expected bool
+	}{
+		{handleOverlay, "/overlay/bingo1", false},
+		{handleOverlay, "/overlay/bingo1/board1?key=wrong", false},
+		{handleOverlay, "/overlay/bingo1?key=watch", true},
+		{handleImage, "/img/bingo1/board1.svg", false},
+		{handleImage, "/img/bingo1/board1.svg?key=watch", true},
+		{handleImage, "/img/bingo1/board1.svg?pass=secret", true},
+	}
+	for _, test := range tests {
+		resp := httptest.NewRecorder()
+		test.handler(resp, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if (resp.Code != http.StatusForbidden) != test.expected {
+			t.Errorf("unexpected status %d for %s", resp.Code, test.path)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package httpserver
+
+import (
+	"Bingo/bingo"
+	"Bingo/webhook"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// webhookView is a webhook of the bingo or its guild with its recent deliveries.
+type webhookView struct {
+	bingo.Webhook
+	// Guild webhooks are managed in discord and shown without their secret.
+	Guild      bool               `json:"guild"`
+	Deliveries []webhook.Delivery `json:"deliveries"`
+}
+
+// handleWebhooksApi manages the webhooks of a bingo under /api/webhooks/{bingo}:
+// GET lists them, POST adds one from the url and events parameters and DELETE
+// removes the one with the id parameter. POST /api/webhooks/{bingo}/test sends a
+// test event to the webhook with the id parameter and returns the delivery.
+func handleWebhooksApi(resp http.ResponseWriter, req *http.Request) {
+	url := strings.Split(req.URL.Path, "/")
+	if len(url) < 4 {
+		http.NotFound(resp, req)
+		return
+	}
+	bin, exists := bingo.Bingos[url[3]]
+	if !exists {
+		http.NotFound(resp, req)
+		return
+	}
+	query := req.URL.Query()
+	if query.Get("pass") != bin.Password {
+		http.Error(resp, "Wrong password", http.StatusForbidden)
+		return
+	}
+
+	if len(url) > 4 && url[4] == "test" {
+		if req.Method != http.MethodPost {
+			http.Error(resp, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		// The webhook is copied so the test delivery does not hold the lock of the bingo.
+		bin.Lock()
+		hook, exists := findWebhook(bin, query.Get("id"))
+		bin.Unlock()
+		if !exists {
+			http.NotFound(resp, req)
+			return
+		}
+		writeJson(resp, webhook.Test(hook, bin))
+		return
+	}
+
+	bin.Lock()
+	defer bin.Unlock()
+	switch req.Method {
+	case http.MethodGet:
+		views := make([]webhookView, 0)
+		for _, hook := range bin.Webhooks {
+			views = append(views, webhookView{Webhook: hook, Deliveries: webhook.Deliveries(hook.Id)})
+		}
+		for _, hook := range bingo.Guild(bin.GuildId).Webhooks {
+			hook.Secret = ""
+			views = append(views, webhookView{Webhook: hook, Guild: true, Deliveries: webhook.Deliveries(hook.Id)})
+		}
+		writeJson(resp, views)
+	case http.MethodPost:
+		events := make([]string, 0)
+		for _, event := range strings.Split(query.Get("events"), ",") {
+			if event = strings.TrimSpace(event); event != "" {
+				events = append(events, event)
+			}
+		}
+		hook, err := bingo.NewWebhook(query.Get("url"), events)
+		if err != nil {
+			http.Error(resp, err.Error(), http.StatusBadRequest)
+			return
+		}
+		bin.Webhooks = append(bin.Webhooks, hook)
+		bin.Store(settings().StoragePath)
+		writeJson(resp, hook)
+	case http.MethodDelete:
+		var removed bool
+		bin.Webhooks, removed = bingo.RemoveWebhook(bin.Webhooks, query.Get("id"))
+		if !removed {
+			http.NotFound(resp, req)
+			return
+		}
+		webhook.Remove(query.Get("id"))
+		bin.Store(settings().StoragePath)
+	default:
+		http.Error(resp, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// findWebhook returns the webhook of the bingo or its guild with the id.
+func findWebhook(bin *bingo.Bingo, id string) (bingo.Webhook, bool) {
+	for _, hook := range append(append([]bingo.Webhook{}, bin.Webhooks...), bingo.Guild(bin.GuildId).Webhooks...) {
+		if hook.Id == id {
+			return hook, true
+		}
+	}
+	return bingo.Webhook{}, false
+}
+
+func writeJson(resp http.ResponseWriter, value interface{}) {
+	resp.Header().Add("content-type", "application/json")
+	json.NewEncoder(resp).Encode(value)
+}
@@ -1,36 +1,335 @@
 package main
 
 import (
+	"Bingo/analytics"
 	"Bingo/bingo"
 	"Bingo/bot"
 	"Bingo/config"
 	"Bingo/httpserver"
+	"Bingo/render"
+	"Bingo/series"
+	"Bingo/sound"
+	"Bingo/timeline"
+	"Bingo/twitch"
+	"Bingo/webhook"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
 func main() {
-	var err error
+	configPath := flag.String("config", "config.json", "path of the config file, empty to only use defaults and environment variables")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: bingo [-config file] [report <kind> | pdf [flags] [bingo id] | import-sound <file.ogg> [name] | import-match [flags] <match.json>]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
 
 	rand.Seed(time.Now().UnixNano())
+
+	cfg, err := config.Load(*configPath)
 	if err != nil {
-		log.WithError(err).Error("Failed to parse the config")
-		return
+		log.WithError(err).Fatal("Invalid config")
 	}
 
-	logLevel, err := log.ParseLevel(config.Json.LogLevel)
+	logLevel, err := log.ParseLevel(cfg.LogLevel)
 	if err != nil {
-		log.WithError(err).Error("Could not parse the loglevel")
-		return
+		log.WithError(err).Fatal("Could not parse the loglevel")
 	}
 	log.SetLevel(logLevel)
+	bingo.SetDefaultRerolls(cfg.GameSettings.TotalRerolls)
+
+	if flag.NArg() > 0 {
+		err = runCommand(cfg, flag.Arg(0), flag.Args()[1:])
+		if err != nil {
+			log.WithError(err).Error("Command failed")
+			os.Exit(1)
+		}
+		return
+	}
 
 	bingo.Bingos = make(map[string]*bingo.Bingo)
-	rand.Seed(time.Now().UnixNano())
+	err = series.LoadAll(cfg.StoragePath)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to load the series")
+	}
+	err = bingo.LoadGuilds(cfg.StoragePath)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to load the guild settings")
+	}
+
+	catalog, err := bingo.LoadCatalog(cfg.WordsPath)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to load the word lists")
+	}
+	bingo.SetCatalog(catalog)
 
-	go bot.InitBot()
+	library := sound.LoadLibrary(cfg.Sound.Path)
+	if len(library.Names()) == 0 {
+		log.WithField("path", cfg.Sound.Path).Warn("No sounds found, audio is disabled")
+	}
+	sound.SetLibrary(library)
 
-	httpserver.Listen()
+	token, err := cfg.Bot.Token()
+	if err != nil {
+		log.WithError(err).Fatal("Invalid config")
+	}
+
+	reloader := &reloader{path: *configPath, cfg: cfg}
+	bot.Reload = reloader.Reload
+	go reloader.Watch()
+
+	webhook.SetConfig(cfg)
+	webhook.Start()
+
+	err = bot.Start(cfg, token)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to start the bot")
+	}
+	defer bot.Stop()
+
+	if len(cfg.Twitch.Channels) > 0 {
+		twitchToken, err := cfg.Twitch.Token()
+		if err != nil {
+			log.WithError(err).Fatal("Failed to read the twitch token")
+		}
+		chat := twitch.Start(cfg, twitchToken)
+		defer chat.Stop()
+	}
+
+	go func() {
+		err := httpserver.Listen(cfg)
+		log.WithError(err).Fatal("Webserver stopped")
+	}()
+
+	// Wait here until CTRL-C or other term signal is received.
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+	<-sc
+	log.Info("Shutting down")
+}
+
+// runCommand executes a command line subcommand instead of starting the bot and webserver.
+func runCommand(cfg *config.Config, command string, args []string) error {
+	switch command {
+	case "report":
+		if len(args) < 1 {
+			return fmt.Errorf("usage: report <kind>")
+		}
+		fields, err := analytics.ForKind(cfg.StoragePath, args[0])
+		if err != nil {
+			return err
+		}
+		analytics.WriteReport(os.Stdout, args[0], fields)
+		return nil
+	case "pdf":
+		return runPDF(cfg, args)
+	case "import-sound":
+		return importSound(cfg, args)
+	case "import-match":
+		return importMatch(cfg, args)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+// runPDF writes printable cards of a stored bingo. Without a bingo id it creates a new
+// bingo of the given kind that is only used for anonymous cards, e.g. for a LAN event.
+func runPDF(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("pdf", flag.ContinueOnError)
+	kind := flags.String("kind", "", "bingo type of a new bingo if no bingo id is given")
+	size := flags.Int("size", 25, "number of fields on the boards of a new bingo")
+	cards := flags.Int("cards", 0, "number of anonymous cards to create and print instead of all boards, at most 64")
+	perPage := flags.Int("per-page", 1, "number of cards on each page")
+	output := flags.String("o", "", "output file, default bingo-<id>.pdf")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: pdf [flags] [bingo id]")
+		flags.PrintDefaults()
+	}
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	var bin *bingo.Bingo
+	switch {
+	case flags.NArg() > 0:
+		bin, err = bingo.Load(cfg.StoragePath, flags.Arg(0))
+	case *kind != "":
+		if *cards <= 0 {
+			return fmt.Errorf("a new bingo needs -cards")
+		}
+		bin, err = bingo.Create(cfg, "", "", *kind, *size)
+	default:
+		flags.Usage()
+		return fmt.Errorf("either a bingo id or -kind is required")
+	}
+	if err != nil {
+		return err
+	}
+
+	var boards []*bingo.BingoBoard
+	if *cards > 0 {
+		boards, err = bin.CreateAnonymousBoards(*cards)
+		if err != nil {
+			return err
+		}
+		err = bin.Store(cfg.StoragePath)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, board := range bin.Boards {
+			boards = append(boards, board)
+		}
+		sort.Slice(boards, func(i, j int) bool { return boards[i].UserName < boards[j].UserName })
+	}
+
+	if *output == "" {
+		*output = "bingo-" + bin.Id + ".pdf"
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = render.PDF(file, bin, boards, *perPage)
+	if err != nil {
+		return err
+	}
+	log.WithField("file", *output).WithField("bingo", bin.Id).Info("Cards written")
+	return file.Close()
+}
+
+// importSound converts an ogg opus file to a dca file in the sound library.
+func importSound(cfg *config.Config, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: import-sound <file.ogg> [name]")
+	}
+	name := strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+	if len(args) > 1 {
+		name = args[1]
+	}
+
+	input, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+	frames, err := sound.ReadOgg(input)
+	if err != nil {
+		return err
+	}
+
+	output := filepath.Join(cfg.Sound.Path, name+".dca")
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	err = sound.WriteDCA(file, frames)
+	if err != nil {
+		return err
+	}
+	log.WithField("file", output).WithField("seconds", len(frames)*sound.FrameSamples/48000).Info("Sound imported")
+	return file.Close()
+}
+
+// importMatch replays a saved match against a stored bingo and marks the fields
+// its rules complete. The fields are marked through the automation endpoint of
+// the running server, which holds the bingo, with the time they happened in the match.
+func importMatch(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("import-match", flag.ContinueOnError)
+	bingoId := flags.String("bingo", "", "id of the bingo")
+	dryRun := flags.Bool("dry-run", false, "only report the fields that would be marked")
+	server := flags.String("url", cfg.BaseUrl, "address of the running server")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: import-match -bingo <id> [flags] <match.json>")
+		flags.PrintDefaults()
+	}
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *bingoId == "" || flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("a bingo id and a match file are required")
+	}
+
+	matchFile, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	match, err := timeline.ParseMatch(matchFile)
+	if err != nil {
+		return err
+	}
+	bin, err := bingo.Load(cfg.StoragePath, *bingoId)
+	if err != nil {
+		return err
+	}
+	if match.Game != "" && match.Game != bin.Kind {
+		log.WithField("game", match.Game).WithField("kind", bin.Kind).Warn("The match is of another game than the bingo")
+	}
+	rules, err := timeline.LoadRules(cfg.WordsPath, bin.Kind)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return fmt.Errorf("there are no rules for %s in %s", bin.Kind, timeline.RulesPath(cfg.WordsPath, bin.Kind))
+	}
+
+	steps := timeline.Plan(bin, timeline.Evaluate(match, rules))
+	timeline.WriteReport(os.Stdout, bin, rules, steps)
+	if *dryRun {
+		return nil
+	}
+	if bin.ApiKey == "" {
+		return fmt.Errorf("the bingo has no automation key yet, run /automation-key in discord once")
+	}
+
+	// Importing the same match again is ignored by the server
+	matchHash := sha256.Sum256(matchFile)
+	client := &http.Client{Timeout: 10 * time.Second}
+	for _, step := range steps {
+		if step.Status != timeline.StatusMark {
+			continue
+		}
+		body, _ := json.Marshal(map[string]interface{}{"event": step.Field, "completed": true, "time": step.Time})
+		req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(*server, "/")+"/api/automation/"+bin.Id, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+bin.ApiKey)
+		req.Header.Set(httpserver.IdempotencyHeader, "match-"+hex.EncodeToString(matchHash[:8])+"-"+step.Field)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		result := bingo.AutomationResult{}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || err != nil {
+			return fmt.Errorf("could not mark %s: server answered %s", step.Field, resp.Status)
+		}
+		log.WithField("field", step.Field).WithField("changed", result.Changed).Info("Field marked")
+	}
+	return nil
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"Bingo/bingo"
+	"Bingo/bot"
+	"Bingo/config"
+	"Bingo/httpserver"
+	"Bingo/sound"
+	"Bingo/webhook"
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// watchInterval is how often the config file, the word lists and the sounds are checked for changes.
+const watchInterval = 5 * time.Second
+
+// reloader replaces the config, the word lists and the sounds at runtime. Running
+// bingos keep the settings they were created with, new bingos use the reloaded ones.
+type reloader struct {
+	path string
+
+	lock sync.Mutex
+	cfg  *config.Config
+}
+
+// Reload loads the config file and the word lists again. If anything is invalid
+// the previous config and word lists stay active.
+func (r *reloader) Reload() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	cfg, err := config.Load(r.path)
+	if err != nil {
+		return err
+	}
+	catalog, err := bingo.LoadCatalog(cfg.WordsPath)
+	if err != nil {
+		return errors.New("could not load the word lists: " + err.Error())
+	}
+
+	for _, change := range config.Diff(r.cfg, cfg) {
+		log.WithField("change", change).Info("Config changed")
+	}
+	for _, setting := range config.RequiresRestart(r.cfg, cfg) {
+		log.WithField("setting", setting).Warn("Changed setting only applies after a restart")
+	}
+	if old := bingo.CurrentCatalog(); old != nil {
+		for _, change := range catalog.Diff(old) {
+			log.WithField("change", change).Info("Word lists changed")
+		}
+	}
+
+	if logLevel, err := log.ParseLevel(cfg.LogLevel); err == nil {
+		log.SetLevel(logLevel)
+	}
+	bingo.SetCatalog(catalog)
+	bingo.SetDefaultRerolls(cfg.GameSettings.TotalRerolls)
+	sound.SetLibrary(sound.LoadLibrary(cfg.Sound.Path))
+	bot.SetConfig(cfg)
+	httpserver.SetConfig(cfg)
+	webhook.SetConfig(cfg)
+	r.cfg = cfg
+	return nil
+}
+
+// Watch reloads on SIGHUP and whenever the config file, a word list or a sound changes.
+func (r *reloader) Watch() {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	fingerprint := r.fingerprint()
+	for {
+		select {
+		case <-hangup:
+			log.Info("Reloading after SIGHUP")
+		case <-ticker.C:
+			current := r.fingerprint()
+			if current == fingerprint {
+				continue
+			}
+			log.Info("Reloading after file change")
+		}
+
+		fingerprint = r.fingerprint()
+		err := r.Reload()
+		if err != nil {
+			log.WithError(err).Error("Reload failed, keeping the previous config")
+		}
+	}
+}
+
+// fingerprint describes the modification state of the config file, the word lists and the sounds.
+func (r *reloader) fingerprint() string {
+	r.lock.Lock()
+	files, _ := filepath.Glob(filepath.Join(r.cfg.WordsPath, "*.txt"))
+	sounds, _ := filepath.Glob(filepath.Join(r.cfg.Sound.Path, "*"))
+	r.lock.Unlock()
+	files = append(files, sounds...)
+	if r.path != "" {
+		files = append(files, r.path)
+	}
+
+	fingerprint := ""
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		fingerprint += file + ":" + strconv.FormatInt(info.ModTime().UnixNano(), 10) + ":" + strconv.FormatInt(info.Size(), 10) + ";"
+	}
+	return fingerprint
+}
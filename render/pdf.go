@@ -0,0 +1,216 @@
+package render
+
+import (
+	"Bingo/bingo"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+const (
+	// pageWidth and pageHeight are the size of an A4 page in points.
+	pageWidth   = 595
+	pageHeight  = 842
+	pageMargin  = 30
+	cardGap     = 20
+	minFontSize = 4
+	maxFontSize = 12
+)
+
+// helveticaWidths are the widths of the printable ASCII characters starting at ' '
+// in the standard Helvetica font, in thousandths of the font size.
+var helveticaWidths = []int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// pdfTextWidth returns the width in points of the text set in Helvetica.
+func pdfTextWidth(text string, fontSize float64) float64 {
+	width := 0
+	for _, r := range text {
+		if r >= ' ' && int(r-' ') < len(helveticaWidths) {
+			width += helveticaWidths[r-' ']
+		} else {
+			width += 556
+		}
+	}
+	return float64(width) * fontSize / 1000
+}
+
+// pdfString encodes the text as a pdf string literal in WinAnsiEncoding.
+func pdfString(text string) string {
+	var buf strings.Builder
+	buf.WriteByte('(')
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r >= ' ' && r < 0x7f || r >= 0xa0 && r <= 0xff:
+			// Latin-1 characters have the same code in WinAnsiEncoding
+			buf.WriteByte(byte(r))
+		default:
+			buf.WriteByte('?')
+		}
+	}
+	buf.WriteByte(')')
+	return buf.String()
+}
+
+// pdfPage collects the content stream of a page. The origin is the top left
+// corner, the y axis is flipped when the drawing commands are written.
+type pdfPage struct {
+	content bytes.Buffer
+}
+
+func (p *pdfPage) text(text string, x, y, fontSize float64, bold bool) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.content, "BT /%s %.1f Tf %.2f %.2f Td %s Tj ET\n", font, fontSize, x, pageHeight-y-fontSize, pdfString(text))
+}
+
+func (p *pdfPage) centeredText(text string, centerX, y, fontSize float64, bold bool) {
+	p.text(text, centerX-pdfTextWidth(text, fontSize)/2, y, fontSize, bold)
+}
+
+func (p *pdfPage) rect(x, y, width, height float64, fill bool) {
+	operator := "S"
+	if fill {
+		operator = "B"
+	}
+	fmt.Fprintf(&p.content, "%.2f %.2f %.2f %.2f re %s\n", x, pageHeight-y-height, width, height, operator)
+}
+
+// PDF writes a printable card for every board with perPage cards on each A4 page.
+// Every card shows the bingo, the player, the grid and the ids to verify a win.
+func PDF(w io.Writer, bin *bingo.Bingo, boards []*bingo.BingoBoard, perPage int) error {
+	if len(boards) == 0 {
+		return errors.New("no boards to print")
+	}
+	if perPage <= 0 {
+		return errors.New("the number of cards per page must be positive")
+	}
+
+	rows := int(math.Ceil(math.Sqrt(float64(perPage))))
+	columns := (perPage + rows - 1) / rows
+	cardWidth := (pageWidth - 2*pageMargin - float64(columns-1)*cardGap) / float64(columns)
+	cardHeight := (pageHeight - 2*pageMargin - float64(rows-1)*cardGap) / float64(rows)
+
+	pages := make([]*pdfPage, 0, (len(boards)+perPage-1)/perPage)
+	for i, board := range boards {
+		if i%perPage == 0 {
+			pages = append(pages, &pdfPage{})
+		}
+		position := i % perPage
+		x := pageMargin + float64(position%columns)*(cardWidth+cardGap)
+		y := pageMargin + float64(position/columns)*(cardHeight+cardGap)
+		drawCard(pages[len(pages)-1], bin, board, x, y, cardWidth, cardHeight)
+	}
+
+	return writePDF(w, pages)
+}
+
+// drawCard draws a single board into the given area of the page.
+func drawCard(page *pdfPage, bin *bingo.Bingo, board *bingo.BingoBoard, x, y, width, height float64) {
+	titleSize := math.Min(24, width/12)
+	nameSize := titleSize * 0.7
+	footerSize := math.Max(minFontSize, titleSize*0.4)
+
+	page.centeredText("BINGO - "+bin.Kind, x+width/2, y, titleSize, true)
+	page.centeredText(board.UserName, x+width/2, y+titleSize*1.3, nameSize, false)
+
+	header := titleSize*1.3 + nameSize*1.6
+	footer := footerSize * 2
+	columns := 1
+	for columns*columns < len(board.Content) {
+		columns++
+	}
+	cellSize := math.Min(width, height-header-footer) / float64(columns)
+	gridX := x + (width-cellSize*float64(columns))/2
+	gridY := y + header
+
+	page.content.WriteString("0.5 w 0 G 0.85 g\n")
+	for index, field := range board.Content {
+		cellX := gridX + float64(index%columns)*cellSize
+		cellY := gridY + float64(index/columns)*cellSize
+		page.rect(cellX, cellY, cellSize, cellSize, bin.IsDone(field))
+		drawCellText(page, field, cellX, cellY, cellSize)
+	}
+
+	rows := (len(board.Content) + columns - 1) / columns
+	page.centeredText("Bingo "+bin.Id+" - Board "+board.Id, x+width/2, gridY+float64(rows)*cellSize+footerSize*0.5, footerSize, false)
+}
+
+// drawCellText wraps the field into the cell with the largest font size that fits.
+func drawCellText(page *pdfPage, field string, x, y, size float64) {
+	padding := size * 0.06
+	fontSize := math.Min(maxFontSize, size/5)
+	var textLines []string
+	for ; fontSize >= minFontSize; fontSize-- {
+		measure := func(text string) int { return int(math.Ceil(pdfTextWidth(text, fontSize))) }
+		textLines = wrap(field, int(size-2*padding), measure)
+		if float64(len(textLines))*fontSize*1.2 <= size-2*padding {
+			break
+		}
+	}
+	if fontSize < minFontSize {
+		fontSize = minFontSize
+	}
+
+	page.content.WriteString("0 g\n")
+	lineY := y + (size-float64(len(textLines))*fontSize*1.2)/2
+	for _, line := range textLines {
+		page.centeredText(line, x+size/2, lineY, fontSize, false)
+		lineY += fontSize * 1.2
+	}
+	page.content.WriteString("0.85 g\n")
+}
+
+// writePDF writes the pages as pdf document using the standard Helvetica fonts.
+func writePDF(w io.Writer, pages []*pdfPage) error {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+
+	kids := make([]string, 0, len(pages))
+	for _, page := range pages {
+		pageId := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageId))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				pageWidth, pageHeight, pageId+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.content.Len(), page.content.String()),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, 0, len(objects))
+	for i, object := range objects {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,77 @@
+package render
+
+import (
+	"Bingo/bingo"
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPDF(t *testing.T) {
+	bin, board := testBoard()
+	boards := []*bingo.BingoBoard{board, board, board, board, board}
+	buffer := &bytes.Buffer{}
+	if err := PDF(buffer, bin, boards, 4); err != nil {
+		t.Fatal(err)
+	}
+	pdf := buffer.String()
+
+	if !strings.HasPrefix(pdf, "%PDF-1.4\n") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Fatal("expected a pdf document")
+	}
+	if !strings.Contains(pdf, "/Count 2 >>") {
+		t.Errorf("expected 5 cards on 2 pages")
+	}
+	if strings.Count(pdf, "(Bingo bingo1 - Board board1)") != 5 {
+		t.Errorf("expected the ids on every card")
+	}
+
+	// Every object must start at the offset in the cross reference table
+	startxref := strings.LastIndex(pdf, "startxref\n")
+	xref, err := strconv.Atoi(strings.TrimSpace(strings.Split(pdf[startxref+len("startxref\n"):], "\n")[0]))
+	if err != nil || !strings.HasPrefix(pdf[xref:], "xref\n") {
+		t.Fatalf("expected startxref to point to the cross reference table")
+	}
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(pdf[xref:], -1)
+	if len(offsets) != 4+2*2 {
+		t.Fatalf("expected 8 objects, got %d", len(offsets))
+	}
+	for i, offset := range offsets {
+		position, _ := strconv.Atoi(offset[1])
+		if !strings.HasPrefix(pdf[position:], strconv.Itoa(i+1)+" 0 obj\n") {
+			t.Errorf("expected object %d at offset %d", i+1, position)
+		}
+	}
+
+	// The stream length must match the content between stream and endstream
+	for _, match := range regexp.MustCompile(`(?s)<< /Length (\d+) >>\nstream\n(.*?)endstream`).FindAllStringSubmatch(pdf, -1) {
+		if length, _ := strconv.Atoi(match[1]); length != len(match[2]) {
+			t.Errorf("expected a stream of %d bytes, got %d", length, len(match[2]))
+		}
+	}
+}
+
+func TestPDFErrors(t *testing.T) {
+	bin, board := testBoard()
+	if err := PDF(&bytes.Buffer{}, bin, nil, 1); err == nil {
+		t.Errorf("expected an error without boards")
+	}
+	if err := PDF(&bytes.Buffer{}, bin, []*bingo.BingoBoard{board}, 0); err == nil {
+		t.Errorf("expected an error without cards per page")
+	}
+}
+
+func TestPDFString(t *testing.T) {
+	for text, expected := range map[string]string{
+		"plain":    "(plain)",
+		"a (b) \\": `(a \(b\) \\)`,
+		"äö":       "(\xe4\xf6)",
+		"🎉":        "(?)",
+	} {
+		if encoded := pdfString(text); encoded != expected {
+			t.Errorf("expected %q for %q, got %q", expected, text, encoded)
+		}
+	}
+}
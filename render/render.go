@@ -0,0 +1,195 @@
+package render
+
+import (
+	"Bingo/bingo"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	cellSize     = 120
+	cellPadding  = 8
+	borderWidth  = 2
+	margin       = 10
+	headerHeight = 40
+	textScale    = 2
+	lineHeight   = (glyphHeight + 3) * textScale
+	// svgCharWidth approximates the width of a character in the svg font.
+	svgCharWidth = 8
+	svgFontSize  = 14
+)
+
+var (
+	backgroundColor = color.RGBA{0x20, 0x20, 0x20, 0xff}
+	cellColor       = color.RGBA{0x21, 0x96, 0xf3, 0xff}
+	completedColor  = color.RGBA{0x21, 0xf3, 0x2c, 0xff}
+	winningColor    = color.RGBA{0xf3, 0xc2, 0x21, 0xff}
+	borderColor     = color.RGBA{0x00, 0x00, 0x00, 0xff}
+	textColor       = color.RGBA{0x00, 0x00, 0x00, 0xff}
+	titleColor      = color.RGBA{0x21, 0xe4, 0x2b, 0xff}
+)
+
+// cell is a field of the board with its position and state.
+type cell struct {
+	Text    string
+	X, Y    int
+	Done    bool
+	Winning bool
+}
+
+// layout positions all cells of the board and returns them with the image size.
+func layout(bin *bingo.Bingo, board *bingo.BingoBoard) ([]cell, int, int) {
+	width := board.Width()
+
+	winning := make(map[int]bool)
+	for _, line := range bin.CompletedLines(board) {
+		for _, index := range line {
+			winning[index] = true
+		}
+	}
+
+	cells := make([]cell, 0, len(board.Content))
+	for index, field := range board.Content {
+		cells = append(cells, cell{
+			Text:    field,
+			X:       margin + (index%width)*cellSize,
+			Y:       margin + headerHeight + (index/width)*cellSize,
+			Done:    bin.IsDone(field),
+			Winning: winning[index],
+		})
+	}
+
+	rows := (len(board.Content) + width - 1) / width
+	return cells, 2*margin + width*cellSize, 2*margin + headerHeight + rows*cellSize
+}
+
+// Title returns the heading of a rendered board.
+func Title(bin *bingo.Bingo, board *bingo.BingoBoard) string {
+	title := board.UserName
+	if placement := bin.Placement(board.Id); placement > 0 {
+		title += " - BINGO #" + strconv.Itoa(placement)
+	}
+	return title
+}
+
+func (c cell) color() color.RGBA {
+	if c.Winning {
+		return winningColor
+	}
+	if c.Done {
+		return completedColor
+	}
+	return cellColor
+}
+
+// Image draws the board with its completion state and winning lines.
+func Image(bin *bingo.Bingo, board *bingo.BingoBoard) *image.RGBA {
+	cells, width, height := layout(bin, board)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{backgroundColor}, image.Point{}, draw.Src)
+
+	title := Title(bin, board)
+	drawText(img, title, (width-textWidth(title, textScale))/2, margin+(headerHeight-glyphHeight*textScale)/2, textScale, titleColor)
+
+	for _, c := range cells {
+		bounds := image.Rect(c.X, c.Y, c.X+cellSize, c.Y+cellSize)
+		draw.Draw(img, bounds, &image.Uniform{borderColor}, image.Point{}, draw.Src)
+		draw.Draw(img, bounds.Inset(borderWidth), &image.Uniform{c.color()}, image.Point{}, draw.Src)
+
+		textLines := wrap(c.Text, cellSize-2*cellPadding, func(text string) int { return textWidth(text, textScale) })
+		y := c.Y + (cellSize-len(textLines)*lineHeight)/2
+		for _, line := range textLines {
+			drawText(img, line, c.X+(cellSize-textWidth(line, textScale))/2, y, textScale, textColor)
+			y += lineHeight
+		}
+	}
+
+	return img
+}
+
+// PNG writes the board as png image.
+func PNG(w io.Writer, bin *bingo.Bingo, board *bingo.BingoBoard) error {
+	return png.Encode(w, Image(bin, board))
+}
+
+// SVG writes the board as svg image.
+func SVG(w io.Writer, bin *bingo.Bingo, board *bingo.BingoBoard) error {
+	cells, width, height := layout(bin, board)
+
+	svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`, width, height, width, height)
+	svg += fmt.Sprintf(`<rect width="100%%" height="100%%" fill="%s"/>`, hex(backgroundColor))
+	svg += fmt.Sprintf(`<text x="%d" y="%d" text-anchor="middle" dominant-baseline="middle" font-size="20" fill="%s">%s</text>`,
+		width/2, margin+headerHeight/2, hex(titleColor), html.EscapeString(Title(bin, board)))
+
+	for _, c := range cells {
+		svg += fmt.Sprintf(`<rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="%s" stroke-width="%d"/>`,
+			c.X, c.Y, cellSize, cellSize, hex(c.color()), hex(borderColor), borderWidth)
+
+		textLines := wrap(c.Text, cellSize-2*cellPadding, func(text string) int { return len([]rune(text)) * svgCharWidth })
+		y := c.Y + cellSize/2 - (len(textLines)-1)*(svgFontSize+2)/2
+		svg += fmt.Sprintf(`<text x="%d" text-anchor="middle" dominant-baseline="middle" font-size="%d" fill="%s">`, c.X+cellSize/2, svgFontSize, hex(textColor))
+		for _, line := range textLines {
+			svg += fmt.Sprintf(`<tspan x="%d" y="%d">%s</tspan>`, c.X+cellSize/2, y, html.EscapeString(line))
+			y += svgFontSize + 2
+		}
+		svg += `</text>`
+	}
+	svg += `</svg>`
+
+	_, err := io.WriteString(w, svg)
+	return err
+}
+
+// wrap splits text into lines no wider than maxWidth, breaking long words if needed.
+func wrap(text string, maxWidth int, measure func(string) int) []string {
+	lines := make([]string, 0)
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if measure(candidate) <= maxWidth {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+
+		line = ""
+		for _, r := range word {
+			if line != "" && measure(line+string(r)) > maxWidth {
+				lines = append(lines, line)
+				line = ""
+			}
+			line += string(r)
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// ETag returns a value that changes whenever the rendered image of the board changes.
+func ETag(bin *bingo.Bingo, board *bingo.BingoBoard) string {
+	hash := fnv.New64a()
+	io.WriteString(hash, Title(bin, board))
+	for _, field := range board.Content {
+		io.WriteString(hash, field+strconv.FormatBool(bin.IsDone(field)))
+	}
+	return `"` + strconv.FormatUint(hash.Sum64(), 36) + `"`
+}
@@ -0,0 +1,106 @@
+package render
+
+import (
+	"Bingo/bingo"
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testBoard() (*bingo.Bingo, *bingo.BingoBoard) {
+	bin := &bingo.Bingo{
+		Id:        "bingo1",
+		Completed: map[string]bool{"a": true, "b": true, "c": true, "d": false, "e": false, "f": false, "g": false, "h": false, "i": false},
+	}
+	board := &bingo.BingoBoard{Id: "board1", UserName: "<player>", Content: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"}}
+	bin.Boards = map[string]*bingo.BingoBoard{board.Id: board}
+	return bin, board
+}
+
+func TestETag(t *testing.T) {
+	bin, board := testBoard()
+	tag := ETag(bin, board)
+	if ETag(bin, board) != tag {
+		t.Fatal("expected the same etag for the same board")
+	}
+	if !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		t.Errorf("expected a quoted etag, got %s", tag)
+	}
+
+	bin.Completed["e"] = true
+	changed := ETag(bin, board)
+	if changed == tag {
+		t.Errorf("expected the etag to change with a completed field")
+	}
+
+	bin.Winners = []bingo.Winner{{BoardId: board.Id, Time: time.Now()}}
+	won := ETag(bin, board)
+	if won == changed {
+		t.Errorf("expected the etag to change with the placement")
+	}
+
+	bin.Completed["h"] = true
+	bin.Completed["h"] = false
+	if ETag(bin, board) != won {
+		t.Errorf("expected the etag to only depend on the current state")
+	}
+}
+
+func TestPNG(t *testing.T) {
+	bin, board := testBoard()
+	buffer := &bytes.Buffer{}
+	if err := PNG(buffer, bin, board); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() != 2*margin+3*cellSize || bounds.Dy() != 2*margin+headerHeight+3*cellSize {
+		t.Errorf("unexpected size %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	// The completed first row is a winning line
+	center := img.At(margin+cellSize/2, margin+headerHeight+cellPadding/2+borderWidth)
+	if r, g, b, _ := center.RGBA(); r>>8 != uint32(winningColor.R) || g>>8 != uint32(winningColor.G) || b>>8 != uint32(winningColor.B) {
+		t.Errorf("expected the winning line to be highlighted, got %v", center)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	bin, board := testBoard()
+	buffer := &bytes.Buffer{}
+	if err := SVG(buffer, bin, board); err != nil {
+		t.Fatal(err)
+	}
+	svg := buffer.String()
+	if !strings.Contains(svg, "&lt;player&gt;") || strings.Contains(svg, "<player>") {
+		t.Errorf("expected the title to be escaped")
+	}
+	if strings.Count(svg, "<rect") != 10 || strings.Count(svg, hex(winningColor)) != 3 {
+		t.Errorf("expected 9 cells with a winning row")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	measure := func(text string) int { return len(text) }
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"short", []string{"short"}},
+		{"two words", []string{"two", "words"}},
+		{"ab cd ef", []string{"ab cd", "ef"}},
+		{"abcdefghij", []string{"abcde", "fghij"}},
+		{"", []string{}},
+	}
+	for _, test := range tests {
+		lines := wrap(test.text, 5, measure)
+		if strings.Join(lines, "|") != strings.Join(test.expected, "|") {
+			t.Errorf("expected %q for %q, got %q", test.expected, test.text, lines)
+		}
+	}
+}
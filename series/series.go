@@ -0,0 +1,183 @@
+package series
+
+import (
+	"Bingo/bingo"
+	"Bingo/random"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+type Series struct {
+	Id      string    `json:"id"`
+	Name    string    `json:"name"`
+	GuildId string    `json:"guildID"`
+	OwnerId string    `json:"ownerID"`
+	Bingos  []string  `json:"bingos"`
+	Scoring Scoring   `json:"scoring"`
+	Closed  bool      `json:"closed"`
+	Created time.Time `json:"created"`
+}
+
+// Scoring defines how many points a player gets for a single bingo.
+// PlacementPoints[0] is awarded for the first bingo, PlacementPoints[1] for the second and so on.
+type Scoring struct {
+	PlacementPoints []int `json:"placementPoints"`
+	LinePoints      int   `json:"linePoints"`
+	CellPoints      int   `json:"cellPoints"`
+}
+
+type Standing struct {
+	PlayerId string `json:"playerID"`
+	UserName string `json:"username"`
+	Points   int    `json:"points"`
+	Games    int    `json:"games"`
+	Wins     int    `json:"wins"`
+	Lines    int    `json:"lines"`
+	Cells    int    `json:"cells"`
+}
+
+var (
+	AllSeries = make(map[string]*Series)
+)
+
+func Create(guildId, ownerId, name string, scoring Scoring) *Series {
+	ser := &Series{
+		Id:      random.RandSeq(16),
+		Name:    name,
+		GuildId: guildId,
+		OwnerId: ownerId,
+		Bingos:  make([]string, 0),
+		Scoring: scoring,
+		Created: time.Now(),
+	}
+
+	AllSeries[ser.Id] = ser
+	return ser
+}
+
+// Active returns the open series of the guild or nil if there is none.
+func Active(guildId string) *Series {
+	var active *Series
+	for _, ser := range AllSeries {
+		if ser.GuildId != guildId || ser.Closed {
+			continue
+		}
+		if active == nil || ser.Created.After(active.Created) {
+			active = ser
+		}
+	}
+	return active
+}
+
+func (s *Series) Add(bin *bingo.Bingo) error {
+	if s.Closed {
+		return errors.New("series is closed")
+	}
+	if bin.GuildId != s.GuildId {
+		return errors.New("bingo belongs to another guild")
+	}
+	for _, id := range s.Bingos {
+		if id == bin.Id {
+			return nil
+		}
+	}
+
+	s.Bingos = append(s.Bingos, bin.Id)
+	return nil
+}
+
+// Score returns the points a board gets in the given bingo.
+func (sc Scoring) Score(bin *bingo.Bingo, board *bingo.BingoBoard) int {
+	points := bin.CountLines(board)*sc.LinePoints + bin.CountCompleted(board)*sc.CellPoints
+
+	placement := bin.Placement(board.Id)
+	if placement > 0 && placement <= len(sc.PlacementPoints) {
+		points += sc.PlacementPoints[placement-1]
+	}
+	return points
+}
+
+// Standings sums up the scores of all bingos in the series, best player first.
+func (s *Series) Standings(path string) ([]*Standing, error) {
+	standings := make(map[string]*Standing)
+
+	for _, id := range s.Bingos {
+		bin, err := bingo.Get(path, id)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, board := range bin.Boards {
+			standing, exists := standings[board.Id]
+			if !exists {
+				standing = &Standing{PlayerId: board.Id}
+				standings[board.Id] = standing
+			}
+
+			standing.UserName = board.UserName
+			standing.Points += s.Scoring.Score(bin, board)
+			standing.Games++
+			standing.Lines += bin.CountLines(board)
+			standing.Cells += bin.CountCompleted(board)
+			if bin.Placement(board.Id) == 1 {
+				standing.Wins++
+			}
+		}
+	}
+
+	sorted := make([]*Standing, 0, len(standings))
+	for _, standing := range standings {
+		sorted = append(sorted, standing)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Points != sorted[j].Points {
+			return sorted[i].Points > sorted[j].Points
+		}
+		return sorted[i].UserName < sorted[j].UserName
+	})
+
+	return sorted, nil
+}
+
+func (s *Series) Store(path string) error {
+	jsonSeries, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(path+"series/", 0755)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path+"series/"+s.Id+".json", jsonSeries, 0644)
+}
+
+// LoadAll reads all stored series into AllSeries.
+func LoadAll(path string) error {
+	files, err := filepath.Glob(path + "series/*.json")
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		jsonSeries, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		ser := &Series{}
+		err = json.Unmarshal(jsonSeries, ser)
+		if err != nil {
+			return err
+		}
+		AllSeries[ser.Id] = ser
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package series
+
+import (
+	"Bingo/bingo"
+	"testing"
+	"time"
+)
+
+// testBingo returns a finished 3x3 bingo in which first and tied share the first
+// placement, second finished later and none has no line.
+func testBingo() *bingo.Bingo {
+	start := time.Now()
+	return &bingo.Bingo{
+		Id:        "bingo1",
+		GuildId:   "guild",
+		Completed: map[string]bool{"a": true, "b": true, "c": true, "d": false, "e": false, "f": false, "g": false, "h": false, "i": false},
+		Boards: map[string]*bingo.BingoBoard{
+			"first":  {Id: "first", UserName: "first", Content: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"}},
+			"tied":   {Id: "tied", UserName: "tied", Content: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"}},
+			"second": {Id: "second", UserName: "second", Content: []string{"d", "e", "f", "a", "b", "c", "g", "h", "i"}},
+			"none":   {Id: "none", UserName: "none", Content: []string{"a", "d", "b", "e", "c", "f", "g", "h", "i"}},
+		},
+		Winners: []bingo.Winner{
+			{BoardId: "first", Time: start},
+			{BoardId: "tied", Time: start},
+			{BoardId: "second", Time: start.Add(time.Minute)},
+		},
+	}
+}
+
+func TestPlacement(t *testing.T) {
+	bin := testBingo()
+	for board, expected := range map[string]int{"first": 1, "tied": 1, "second": 3, "none": 0, "unknown": 0} {
+		if placement := bin.Placement(board); placement != expected {
+			t.Errorf("expected placement %d for %s, got %d", expected, board, placement)
+		}
+	}
+}
+
+func TestScore(t *testing.T) {
+	bin := testBingo()
+	tests := []struct {
+		name     string
+		scoring  Scoring
+		board    string
+		expected int
+	}{
+		{"placement", Scoring{PlacementPoints: []int{10, 5, 3}}, "first", 10},
+		{"tie shares the placement", Scoring{PlacementPoints: []int{10, 5, 3}}, "tied", 10},
+		{"placement after a tie", Scoring{PlacementPoints: []int{10, 5, 3}}, "second", 3},
+		{"placement without points", Scoring{PlacementPoints: []int{10, 5}}, "second", 0},
+		{"no bingo", Scoring{PlacementPoints: []int{10, 5, 3}}, "none", 0},
+		{"lines", Scoring{LinePoints: 2}, "first", 2},
+		{"no lines", Scoring{LinePoints: 2}, "none", 0},
+		{"cells", Scoring{CellPoints: 1}, "none", 3},
+		{"everything", Scoring{PlacementPoints: []int{10, 5, 3}, LinePoints: 2, CellPoints: 1}, "second", 8},
+	}
+	for _, test := range tests {
+		if score := test.scoring.Score(bin, bin.Boards[test.board]); score != test.expected {
+			t.Errorf("%s: expected %d points for %s, got %d", test.name, test.expected, test.board, score)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	bin := testBingo()
+	ser := &Series{GuildId: "guild"}
+
+	for i := 0; i < 2; i++ {
+		if err := ser.Add(bin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(ser.Bingos) != 1 {
+		t.Errorf("expected a bingo added twice to count once, got %v", ser.Bingos)
+	}
+
+	if err := ser.Add(&bingo.Bingo{Id: "other", GuildId: "other"}); err == nil {
+		t.Errorf("expected an error for a bingo of another guild")
+	}
+	ser.Closed = true
+	if err := ser.Add(&bingo.Bingo{Id: "later", GuildId: "guild"}); err == nil {
+		t.Errorf("expected an error for a closed series")
+	}
+}
+
+func TestStandings(t *testing.T) {
+	bin := testBingo()
+	bingo.Bingos = map[string]*bingo.Bingo{bin.Id: bin}
+	ser := &Series{GuildId: "guild", Scoring: Scoring{PlacementPoints: []int{10, 5, 3}, LinePoints: 2, CellPoints: 1}}
+	ser.Add(bin)
+	ser.Add(bin)
+
+	standings, err := ser.Standings(t.TempDir() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		player string
+		points int
+		wins   int
+	}{{"first", 15, 1}, {"tied", 15, 1}, {"second", 8, 0}, {"none", 3, 0}}
+	if len(standings) != len(expected) {
+		t.Fatalf("expected %d standings, got %d", len(expected), len(standings))
+	}
+	for i, standing := range standings {
+		if standing.PlayerId != expected[i].player || standing.Points != expected[i].points || standing.Wins != expected[i].wins || standing.Games != 1 {
+			t.Errorf("expected %+v at %d, got %+v", expected[i], i+1, standing)
+		}
+	}
+}
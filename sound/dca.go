@@ -0,0 +1,54 @@
+package sound
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ReadDCA reads the opus frames of a dca file. Every frame is stored as its
+// length as little endian int16 followed by the encoded frame.
+func ReadDCA(r io.Reader) ([][]byte, error) {
+	frames := make([][]byte, 0)
+	var opuslen int16
+
+	for {
+		// Read opus frame length from dca file.
+		err := binary.Read(r, binary.LittleEndian, &opuslen)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return frames, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if opuslen <= 0 {
+			return nil, errors.New("invalid dca frame length")
+		}
+
+		// Read encoded pcm from dca file.
+		frame := make([]byte, opuslen)
+		_, err = io.ReadFull(r, frame)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame)
+	}
+}
+
+// WriteDCA writes the opus frames in the format read by ReadDCA.
+func WriteDCA(w io.Writer, frames [][]byte) error {
+	for _, frame := range frames {
+		if len(frame) == 0 || len(frame) > 0x7fff {
+			return errors.New("invalid opus frame length")
+		}
+		err := binary.Write(w, binary.LittleEndian, int16(len(frame)))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(frame)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package sound
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Events a sound can be selected for.
+const (
+	EventFirst = "first"
+	EventLine  = "line"
+	EventEnd   = "end"
+	// None selects silence for an event.
+	None = "none"
+)
+
+var Events = []string{EventFirst, EventLine, EventEnd}
+
+// Library holds the opus frames of all sounds by name. It is never modified
+// after loading, reloading replaces it as a whole.
+type Library struct {
+	sounds map[string][][]byte
+}
+
+var library atomic.Pointer[Library]
+
+// LoadLibrary reads every dca, ogg and opus file in the directory. The file
+// name without extension is the name of the sound. Files that cannot be read
+// are skipped so a broken sound only disables itself.
+func LoadLibrary(path string) *Library {
+	l := &Library{sounds: make(map[string][][]byte)}
+
+	files, _ := filepath.Glob(filepath.Join(path, "*"))
+	for _, file := range files {
+		extension := strings.ToLower(filepath.Ext(file))
+		if extension != ".dca" && extension != ".ogg" && extension != ".opus" {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		if _, exists := l.sounds[name]; exists {
+			log.WithField("file", file).Warn("Skipping sound with a duplicate name")
+			continue
+		}
+
+		frames, err := readFile(file, extension)
+		if err != nil {
+			log.WithError(err).WithField("file", file).Warn("Skipping sound that cannot be read")
+			continue
+		}
+		if len(frames) == 0 {
+			log.WithField("file", file).Warn("Skipping empty sound")
+			continue
+		}
+		l.sounds[name] = frames
+	}
+	return l
+}
+
+func readFile(file, extension string) ([][]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if extension == ".dca" {
+		return ReadDCA(f)
+	}
+	return ReadOgg(f)
+}
+
+// Get returns the frames of the sound and whether it exists.
+func (l *Library) Get(name string) ([][]byte, bool) {
+	frames, exists := l.sounds[name]
+	return frames, exists
+}
+
+// Names returns the names of all sounds in alphabetical order.
+func (l *Library) Names() []string {
+	names := make([]string, 0, len(l.sounds))
+	for name := range l.sounds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Current returns the library sounds are played from. It is empty if none was set.
+func Current() *Library {
+	if l := library.Load(); l != nil {
+		return l
+	}
+	return &Library{sounds: make(map[string][][]byte)}
+}
+
+// SetLibrary atomically replaces the library.
+func SetLibrary(l *Library) {
+	library.Store(l)
+}
@@ -0,0 +1,257 @@
+package sound
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+)
+
+const (
+	oggHeaderSize = 27
+	oggContinued  = 0x01
+	oggFirstPage  = 0x02
+	oggLastPage   = 0x04
+	// maxPagePackets keeps written pages well below the limit of 255 segments.
+	maxPagePackets = 50
+	// preSkip is the number of samples the decoder drops at the start, the
+	// value recommended for the encoder delay of libopus.
+	preSkip = 312
+	// FrameSamples is the length of a 20ms frame at 48kHz, the only frame
+	// length discord plays at the right speed.
+	FrameSamples = 960
+)
+
+var oggCRCTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		crc := uint32(i) << 24
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+func oggCRC(data []byte) uint32 {
+	var crc uint32
+	for _, b := range data {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+// ReadOgg reads the opus packets of the first stream of an ogg opus file. It
+// fails if the frames are not 20ms long as discord would play them too fast or too slow.
+func ReadOgg(r io.Reader) ([][]byte, error) {
+	packets := make([][]byte, 0)
+	var packet []byte
+	var serial uint32
+	first := true
+
+	header := make([]byte, oggHeaderSize)
+	for {
+		_, err := io.ReadFull(r, header)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if string(header[:4]) != "OggS" || header[4] != 0 {
+			return nil, errors.New("not an ogg file")
+		}
+
+		segments := make([]byte, header[26])
+		_, err = io.ReadFull(r, segments)
+		if err != nil {
+			return nil, err
+		}
+		size := 0
+		for _, segment := range segments {
+			size += int(segment)
+		}
+		data := make([]byte, size)
+		_, err = io.ReadFull(r, data)
+		if err != nil {
+			return nil, err
+		}
+
+		page := append(append(append([]byte{}, header...), segments...), data...)
+		expected := binary.LittleEndian.Uint32(page[22:26])
+		binary.LittleEndian.PutUint32(page[22:26], 0)
+		if oggCRC(page) != expected {
+			return nil, errors.New("ogg page checksum mismatch")
+		}
+
+		pageSerial := binary.LittleEndian.Uint32(header[14:18])
+		if first {
+			serial = pageSerial
+			first = false
+		}
+		if pageSerial != serial {
+			// Only the first logical stream is read
+			continue
+		}
+		if header[5]&oggContinued == 0 {
+			packet = nil
+		}
+
+		offset := 0
+		for _, segment := range segments {
+			packet = append(packet, data[offset:offset+int(segment)]...)
+			offset += int(segment)
+			if segment < 255 {
+				packets = append(packets, packet)
+				packet = nil
+			}
+		}
+	}
+
+	if len(packets) < 2 || !bytes.HasPrefix(packets[0], []byte("OpusHead")) || !bytes.HasPrefix(packets[1], []byte("OpusTags")) {
+		return nil, errors.New("not an ogg opus file")
+	}
+	frames := packets[2:]
+	for i, frame := range frames {
+		if samples := PacketSamples(frame); samples != FrameSamples {
+			return nil, errors.New("frame " + strconv.Itoa(i) + " is " + strconv.Itoa(samples/48) + "ms long, only 20ms frames are supported")
+		}
+	}
+	return frames, nil
+}
+
+// PacketSamples returns the number of 48kHz samples in an opus packet from its table of contents.
+func PacketSamples(packet []byte) int {
+	if len(packet) == 0 {
+		return 0
+	}
+
+	toc := packet[0]
+	config := int(toc >> 3)
+	var frameSamples int
+	switch {
+	case config < 12:
+		// SILK: 10, 20, 40 and 60ms
+		frameSamples = []int{480, 960, 1920, 2880}[config%4]
+	case config < 16:
+		// Hybrid: 10 and 20ms
+		frameSamples = []int{480, 960}[config%2]
+	default:
+		// CELT: 2.5, 5, 10 and 20ms
+		frameSamples = []int{120, 240, 480, 960}[config%4]
+	}
+
+	switch toc & 0x03 {
+	case 0:
+		return frameSamples
+	case 1, 2:
+		return 2 * frameSamples
+	default:
+		if len(packet) < 2 {
+			return 0
+		}
+		return int(packet[1]&0x3f) * frameSamples
+	}
+}
+
+// WriteOgg writes the opus frames as ogg opus file that can be played by media players.
+func WriteOgg(w io.Writer, frames [][]byte) error {
+	if len(frames) == 0 {
+		return errors.New("no frames to write")
+	}
+	for _, frame := range frames {
+		if len(frame) == 0 {
+			return errors.New("invalid opus frame length")
+		}
+	}
+
+	channels := byte(1)
+	if frames[0][0]&0x04 != 0 {
+		channels = 2
+	}
+	head := []byte("OpusHead")
+	head = append(head, 1, channels)
+	head = binary.LittleEndian.AppendUint16(head, preSkip)
+	head = binary.LittleEndian.AppendUint32(head, 48000)
+	head = append(head, 0, 0, 0)
+
+	tags := []byte("OpusTags")
+	vendor := "Bingo"
+	tags = binary.LittleEndian.AppendUint32(tags, uint32(len(vendor)))
+	tags = append(tags, vendor...)
+	tags = binary.LittleEndian.AppendUint32(tags, 0)
+
+	writer := oggWriter{w: w, serial: 0x42494e47}
+	err := writer.page([][]byte{head}, 0, oggFirstPage)
+	if err != nil {
+		return err
+	}
+	err = writer.page([][]byte{tags}, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	granule := uint64(preSkip)
+	for start := 0; start < len(frames); start += maxPagePackets {
+		end := start + maxPagePackets
+		flags := byte(0)
+		if end >= len(frames) {
+			end = len(frames)
+			flags = oggLastPage
+		}
+		for _, frame := range frames[start:end] {
+			granule += uint64(PacketSamples(frame))
+		}
+		err = writer.page(frames[start:end], granule, flags)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type oggWriter struct {
+	w        io.Writer
+	serial   uint32
+	sequence uint32
+}
+
+// page writes complete packets as a single ogg page.
+func (o *oggWriter) page(packets [][]byte, granule uint64, flags byte) error {
+	segments := make([]byte, 0)
+	data := make([]byte, 0)
+	for _, packet := range packets {
+		for remaining := len(packet); ; remaining -= 255 {
+			if remaining < 255 {
+				segments = append(segments, byte(remaining))
+				break
+			}
+			segments = append(segments, 255)
+		}
+		data = append(data, packet...)
+	}
+	if len(segments) > 255 {
+		return errors.New("ogg page too large")
+	}
+
+	page := []byte("OggS")
+	page = append(page, 0, flags)
+	page = binary.LittleEndian.AppendUint64(page, granule)
+	page = binary.LittleEndian.AppendUint32(page, o.serial)
+	page = binary.LittleEndian.AppendUint32(page, o.sequence)
+	page = binary.LittleEndian.AppendUint32(page, 0)
+	page = append(page, byte(len(segments)))
+	page = append(page, segments...)
+	page = append(page, data...)
+	binary.LittleEndian.PutUint32(page[22:26], oggCRC(page))
+
+	o.sequence++
+	_, err := o.w.Write(page)
+	return err
+}
@@ -0,0 +1,90 @@
+package sound
+
+import (
+	"bytes"
+	"testing"
+)
+
+// testFrames returns 20ms CELT stereo frames of different sizes, some spanning several ogg segments.
+func testFrames() [][]byte {
+	frames := make([][]byte, 0)
+	for i := 0; i < 120; i++ {
+		frame := make([]byte, 10+i*5)
+		frame[0] = 31<<3 | 0x04
+		for j := 1; j < len(frame); j++ {
+			frame[j] = byte(i + j)
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func TestDCARoundTrip(t *testing.T) {
+	frames := testFrames()
+	var buf bytes.Buffer
+	err := WriteDCA(&buf, frames)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadDCA(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(frames) || !bytes.Equal(read[57], frames[57]) {
+		t.Errorf("frames changed")
+	}
+}
+
+func TestOggRoundTrip(t *testing.T) {
+	frames := testFrames()
+	var buf bytes.Buffer
+	err := WriteOgg(&buf, frames)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadOgg(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(frames) {
+		t.Fatalf("expected %d frames, got %d", len(frames), len(read))
+	}
+	for i := range frames {
+		if !bytes.Equal(read[i], frames[i]) {
+			t.Fatalf("frame %d changed", i)
+		}
+	}
+}
+
+func TestOggRejectsOtherFrameLengths(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame []byte
+	}{
+		// Discord would play 10ms frames too fast and 40ms frames too slow
+		{"10ms CELT", []byte{30 << 3, 1, 2, 3}},
+		{"40ms SILK", []byte{2 << 3, 1, 2, 3}},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		err := WriteOgg(&buf, [][]byte{test.frame})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ReadOgg(&buf)
+		if err == nil {
+			t.Errorf("expected %s frames to be rejected", test.name)
+		}
+	}
+}
+
+func TestWriteOggRejectsEmptyFrames(t *testing.T) {
+	for _, frames := range [][][]byte{nil, {{}}, {{31 << 3, 1}, {}}} {
+		if err := WriteOgg(&bytes.Buffer{}, frames); err == nil {
+			t.Errorf("expected an error for the frames %v", frames)
+		}
+	}
+}
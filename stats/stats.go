@@ -0,0 +1,108 @@
+package stats
+
+import (
+	"Bingo/bingo"
+	"errors"
+	"sort"
+)
+
+// PlayerStats aggregates the results of a player over all stored games of a guild.
+type PlayerStats struct {
+	PlayerId     string  `json:"playerID"`
+	UserName     string  `json:"username"`
+	Games        int     `json:"games"`
+	Wins         int     `json:"wins"`
+	Cells        int     `json:"cells"`
+	AverageCells float64 `json:"averageCells"`
+	RerollsUsed  int     `json:"rerollsUsed"`
+	// FastestBingo is the shortest time in seconds from the start of a game to
+	// the players first line. It is 0 if the player never got a bingo.
+	FastestBingo int `json:"fastestBingoSeconds"`
+}
+
+var (
+	ErrNoStats = errors.New("no games recorded for this player")
+)
+
+// ForGuild collects the stats of every player that played in the guild.
+func ForGuild(path, guildId string) (map[string]*PlayerStats, error) {
+	bins, err := bingo.LoadAll(path)
+	if err != nil {
+		return nil, err
+	}
+
+	players := make(map[string]*PlayerStats)
+	for _, bin := range bins {
+		if bin.GuildId != guildId {
+			continue
+		}
+
+		for _, board := range bin.Boards {
+			player, exists := players[board.Id]
+			if !exists {
+				player = &PlayerStats{PlayerId: board.Id}
+				players[board.Id] = player
+			}
+
+			player.UserName = board.UserName
+			player.Games++
+			player.Cells += bin.CountCompleted(board)
+			player.RerollsUsed += board.RerollsUsed
+			if bin.Placement(board.Id) == 1 {
+				player.Wins++
+			}
+
+			for _, winner := range bin.Winners {
+				if winner.BoardId != board.Id || bin.Created.IsZero() {
+					continue
+				}
+				seconds := int(winner.Time.Sub(bin.Created).Seconds())
+				if player.FastestBingo == 0 || seconds < player.FastestBingo {
+					player.FastestBingo = seconds
+				}
+			}
+		}
+	}
+
+	for _, player := range players {
+		player.AverageCells = float64(player.Cells) / float64(player.Games)
+	}
+	return players, nil
+}
+
+// ForPlayer returns the stats of a single player in the guild.
+func ForPlayer(path, guildId, playerId string) (*PlayerStats, error) {
+	players, err := ForGuild(path, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	player, exists := players[playerId]
+	if !exists {
+		return nil, ErrNoStats
+	}
+	return player, nil
+}
+
+// Leaderboard returns all players of the guild ordered by wins and average completed cells.
+func Leaderboard(path, guildId string) ([]*PlayerStats, error) {
+	players, err := ForGuild(path, guildId)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]*PlayerStats, 0, len(players))
+	for _, player := range players {
+		sorted = append(sorted, player)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Wins != sorted[j].Wins {
+			return sorted[i].Wins > sorted[j].Wins
+		}
+		if sorted[i].AverageCells != sorted[j].AverageCells {
+			return sorted[i].AverageCells > sorted[j].AverageCells
+		}
+		return sorted[i].UserName < sorted[j].UserName
+	})
+	return sorted, nil
+}
@@ -0,0 +1,79 @@
+package stats
+
+import (
+	"Bingo/bingo"
+	"testing"
+	"time"
+)
+
+// storeGame stores a finished 2x2 game of the guild in which winner got a bingo
+// after the given time and loser completed a single field.
+func storeGame(t *testing.T, path, id, guildId, winner, loser string, after time.Duration) {
+	created := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	bin := &bingo.Bingo{
+		Kind:      "test",
+		Id:        id,
+		GuildId:   guildId,
+		Created:   created,
+		Completed: map[string]bool{"a": true, "b": true, "c": false, "d": false},
+		Boards: map[string]*bingo.BingoBoard{
+			winner: {Id: winner, UserName: winner, Content: []string{"a", "b", "c", "d"}, RerollsUsed: 1},
+			loser:  {Id: loser, UserName: loser, Content: []string{"a", "c", "d", "e"}},
+		},
+		Winners: []bingo.Winner{{BoardId: winner, Time: created.Add(after)}},
+	}
+	if err := bin.Store(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestForPlayer(t *testing.T) {
+	path := t.TempDir() + "/"
+	storeGame(t, path, "game1", "guild", "alice", "bob", 90*time.Second)
+	storeGame(t, path, "game2", "guild", "alice", "bob", 30*time.Second)
+	storeGame(t, path, "game3", "other", "bob", "alice", 10*time.Second)
+
+	alice, err := ForPlayer(path, "guild", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice.Games != 2 || alice.Wins != 2 || alice.Cells != 4 || alice.AverageCells != 2 || alice.RerollsUsed != 2 {
+		t.Errorf("unexpected stats %+v", alice)
+	}
+	if alice.FastestBingo != 30 {
+		t.Errorf("expected the fastest bingo after 30 seconds, got %d", alice.FastestBingo)
+	}
+
+	bob, err := ForPlayer(path, "guild", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bob.Games != 2 || bob.Wins != 0 || bob.FastestBingo != 0 || bob.AverageCells != 1 {
+		t.Errorf("expected the games of other guilds to be ignored, got %+v", bob)
+	}
+
+	if _, err = ForPlayer(path, "guild", "carol"); err != ErrNoStats {
+		t.Errorf("expected ErrNoStats, got %v", err)
+	}
+}
+
+func TestLeaderboard(t *testing.T) {
+	path := t.TempDir() + "/"
+	storeGame(t, path, "game1", "guild", "bob", "carol", time.Minute)
+	storeGame(t, path, "game2", "guild", "alice", "dave", time.Minute)
+	storeGame(t, path, "game3", "guild", "bob", "alice", time.Minute)
+
+	leaderboard, err := Leaderboard(path, "guild")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"bob", "alice", "carol", "dave"}
+	if len(leaderboard) != len(expected) {
+		t.Fatalf("expected %d players, got %d", len(expected), len(leaderboard))
+	}
+	for i, player := range leaderboard {
+		if player.PlayerId != expected[i] {
+			t.Errorf("expected %s at %d, got %s", expected[i], i+1, player.PlayerId)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Package timeline replays saved matches against a bingo. Rules of the bingo
+// type decide which fields the events of the match complete.
+//
+// A match file is JSON in this schema, exported e.g. from a match tracker:
+//
+//	{
+//	  "game": "valorant",
+//	  "started": "2024-05-01T20:00:00Z",
+//	  "rounds": [
+//	    {
+//	      "number": 1,
+//	      "start": 0,
+//	      "attributes": {"winner": "attackers", "alive": 7},
+//	      "events": [
+//	        {"time": 21.5, "type": "kill", "actor": "player1",
+//	         "attributes": {"weapon": "Sheriff", "headshot": true}},
+//	        {"time": 98, "type": "roundEnd", "attributes": {"thrifty": true}}
+//	      ]
+//	    }
+//	  ]
+//	}
+//
+// Times are seconds since the start of the match, which is required. Every
+// round ends with a roundEnd event. The state of the round, like the players
+// alive at its end, are attributes of the round that rules read as "round.alive".
+// The attributes are free-form so the rules of every game can use whatever its
+// tracker exports.
+package timeline
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Match struct {
+	Game    string    `json:"game"`
+	Started time.Time `json:"started"`
+	Rounds  []Round   `json:"rounds"`
+}
+
+type Round struct {
+	Number int `json:"number"`
+	// Start is the time the round started, in seconds since the start of the match.
+	Start      float64                `json:"start"`
+	Attributes map[string]interface{} `json:"attributes"`
+	Events     []Event                `json:"events"`
+}
+
+type Event struct {
+	// Time is the time of the event in seconds since the start of the match.
+	Time       float64                `json:"time"`
+	Type       string                 `json:"type"`
+	Actor      string                 `json:"actor"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// LoadMatch reads a match file.
+func LoadMatch(path string) (*Match, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMatch(content)
+}
+
+// ParseMatch parses the content of a match file.
+func ParseMatch(content []byte) (*Match, error) {
+	match := &Match{}
+	err := json.Unmarshal(content, match)
+	if err != nil {
+		return nil, err
+	}
+	if match.Started.IsZero() {
+		return nil, errors.New("the match has no start time")
+	}
+	if len(match.Rounds) == 0 {
+		return nil, errors.New("the match has no rounds")
+	}
+	return match, nil
+}
+
+// roundEvent is an event with the round it happened in.
+type roundEvent struct {
+	Event
+	Round *Round
+}
+
+// events returns all events of the match in chronological order.
+func (m *Match) events() []roundEvent {
+	events := make([]roundEvent, 0)
+	for i := range m.Rounds {
+		for _, event := range m.Rounds[i].Events {
+			events = append(events, roundEvent{Event: event, Round: &m.Rounds[i]})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time < events[j].Time })
+	return events
+}
+
+// attribute returns an attribute of the event. Besides its own attributes an
+// event has "actor", "roundTime" (seconds since the round started), "round.number"
+// and the attributes of its round prefixed with "round.".
+func (e roundEvent) attribute(key string) (interface{}, bool) {
+	switch key {
+	case "actor":
+		return e.Actor, true
+	case "roundTime":
+		return e.Time - e.Round.Start, true
+	case "round.number":
+		return float64(e.Round.Number), true
+	}
+	if strings.HasPrefix(key, "round.") {
+		value, exists := e.Round.Attributes[strings.TrimPrefix(key, "round.")]
+		return value, exists
+	}
+	value, exists := e.Attributes[key]
+	return value, exists
+}
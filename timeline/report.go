@@ -0,0 +1,83 @@
+package timeline
+
+import (
+	"Bingo/bingo"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Statuses of a step of a replay.
+const (
+	StatusMark        = "mark"
+	StatusMarked      = "already marked"
+	StatusNotInBingo  = "not in bingo"
+	StatusEarlierRule = "marked by an earlier rule"
+)
+
+// Step is what a hit does to the bingo.
+type Step struct {
+	Hit
+	// Field is the field of the bingo the rule completes, empty if the bingo does not have it.
+	Field  string
+	Status string
+}
+
+// Plan returns the steps of the hits against the bingo in chronological order.
+func Plan(bin *bingo.Bingo, hits []Hit) []Step {
+	steps := make([]Step, 0, len(hits))
+	marked := make(map[string]bool)
+	for _, hit := range hits {
+		step := Step{Hit: hit, Field: findField(bin, hit.Rule.Field)}
+		switch {
+		case step.Field == "":
+			step.Status = StatusNotInBingo
+		case marked[step.Field]:
+			step.Status = StatusEarlierRule
+		case bin.Completed[step.Field]:
+			step.Status = StatusMarked
+		default:
+			step.Status = StatusMark
+			marked[step.Field] = true
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// findField returns the field of the bingo with the name, ignoring the case.
+func findField(bin *bingo.Bingo, name string) string {
+	if _, exists := bin.Completed[name]; exists {
+		return name
+	}
+	for field := range bin.Completed {
+		if strings.EqualFold(field, name) {
+			return field
+		}
+	}
+	return ""
+}
+
+// WriteReport describes the replay of the match against the bingo, including the rules without a hit.
+func WriteReport(w io.Writer, bin *bingo.Bingo, rules []Rule, steps []Step) {
+	fmt.Fprintf(w, "Match replay for bingo %s (%s)\n", bin.Id, bin.Kind)
+	fmt.Fprintf(w, "%-8s %5s %-30s %-28s %s\n", "Time", "Round", "Rule", "Field", "Status")
+
+	hitRules := make(map[string]bool)
+	toMark := 0
+	for _, step := range steps {
+		hitRules[step.Rule.String()+"\x00"+step.Rule.Field] = true
+		if step.Status == StatusMark {
+			toMark++
+		}
+		offset := int(step.Offset)
+		fmt.Fprintf(w, "%3d:%02d   %5d %-30s %-28s %s\n", offset/60, offset%60, step.Round, step.Rule, step.Rule.Field, step.Status)
+	}
+
+	for _, rule := range rules {
+		if !hitRules[rule.String()+"\x00"+rule.Field] {
+			fmt.Fprintf(w, "%-8s %5s %-30s %-28s %s\n", "-", "-", rule, rule.Field, "no hit")
+		}
+	}
+	fmt.Fprintf(w, "%d fields to mark\n", toMark)
+}
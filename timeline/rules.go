@@ -0,0 +1,193 @@
+package timeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule completes a field when enough events match its conditions. The rules of a
+// bingo type are stored next to its word list as <kind>.rules.json.
+type Rule struct {
+	// Name describes the rule in reports, e.g. "Sheriff kill in a pistol round".
+	Name  string `json:"name"`
+	Field string `json:"field"`
+	// Event is the type of the events the rule counts, any type if empty.
+	Event string `json:"event"`
+	// Where are conditions on the attributes of the events. A value matches
+	// itself, a list matches any of its values and an object with min and max
+	// matches the numbers in between, both inclusive.
+	Where map[string]interface{} `json:"where"`
+	// Count is the number of matching events needed, 1 if unset.
+	Count int `json:"count"`
+	// PerRound only counts events of the same round together.
+	PerRound bool `json:"perRound"`
+	// PerActor only counts events of the same actor together.
+	PerActor bool `json:"perActor"`
+}
+
+// Hit is the moment a rule completed its field.
+type Hit struct {
+	Rule  Rule
+	Round int
+	Actor string
+	// Offset is the time of the hit in seconds since the start of the match.
+	Offset float64
+	Time   time.Time
+}
+
+// RulesPath returns the file with the rules of the bingo type.
+func RulesPath(wordsPath, kind string) string {
+	return filepath.Join(wordsPath, kind+".rules.json")
+}
+
+// LoadRules reads the rules of the bingo type. A type without rules file has no rules.
+func LoadRules(wordsPath, kind string) ([]Rule, error) {
+	content, err := ioutil.ReadFile(RulesPath(wordsPath, kind))
+	if os.IsNotExist(err) {
+		return []Rule{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]Rule, 0)
+	err = json.Unmarshal(content, &rules)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the rules of %s: %w", kind, err)
+	}
+	for i, rule := range rules {
+		err = rule.validate()
+		if err != nil {
+			return nil, fmt.Errorf("rule %d of %s: %w", i+1, kind, err)
+		}
+	}
+	return rules, nil
+}
+
+func (r Rule) validate() error {
+	if r.Field == "" {
+		return errors.New("no field")
+	}
+	if r.Count < 0 {
+		return errors.New("the count must not be negative")
+	}
+	for key, expected := range r.Where {
+		if bounds, isRange := expected.(map[string]interface{}); isRange {
+			for bound, value := range bounds {
+				if _, isNumber := value.(float64); (bound != "min" && bound != "max") || !isNumber {
+					return fmt.Errorf("the range of %s may only have the numbers min and max", key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// String returns the name of the rule or describes its conditions.
+func (r Rule) String() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	description := r.Event
+	if description == "" {
+		description = "any event"
+	}
+	if r.Count > 1 {
+		description = strconv.Itoa(r.Count) + "x " + description
+	}
+	return description
+}
+
+// matches reports whether the event fulfills the conditions of the rule.
+func (r Rule) matches(event roundEvent) bool {
+	if r.Event != "" && r.Event != event.Type {
+		return false
+	}
+	for key, expected := range r.Where {
+		actual, exists := event.attribute(key)
+		if !exists || !matchesValue(actual, expected) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesValue(actual, expected interface{}) bool {
+	switch expected := expected.(type) {
+	case []interface{}:
+		for _, value := range expected {
+			if matchesValue(actual, value) {
+				return true
+			}
+		}
+		return false
+	case map[string]interface{}:
+		number, isNumber := actual.(float64)
+		if !isNumber {
+			return false
+		}
+		if min, exists := expected["min"]; exists && number < min.(float64) {
+			return false
+		}
+		if max, exists := expected["max"]; exists && number > max.(float64) {
+			return false
+		}
+		return true
+	case string:
+		text, isText := actual.(string)
+		return isText && strings.EqualFold(text, expected)
+	default:
+		return actual == expected
+	}
+}
+
+// Evaluate replays the match and returns the first hit of every rule in chronological order.
+func Evaluate(match *Match, rules []Rule) []Hit {
+	counts := make([]map[string]int, len(rules))
+	for i := range counts {
+		counts[i] = make(map[string]int)
+	}
+
+	hits := make([]Hit, 0)
+	hit := make([]bool, len(rules))
+	for _, event := range match.events() {
+		for i, rule := range rules {
+			if hit[i] || !rule.matches(event) {
+				continue
+			}
+
+			group := ""
+			if rule.PerRound {
+				group += strconv.Itoa(event.Round.Number)
+			}
+			if rule.PerActor {
+				group += "/" + event.Actor
+			}
+			counts[i][group]++
+			needed := rule.Count
+			if needed == 0 {
+				needed = 1
+			}
+			if counts[i][group] < needed {
+				continue
+			}
+
+			hit[i] = true
+			hits = append(hits, Hit{
+				Rule:   rule,
+				Round:  event.Round.Number,
+				Actor:  event.Actor,
+				Offset: event.Time,
+				Time:   match.Started.Add(time.Duration(event.Time * float64(time.Second))),
+			})
+		}
+	}
+	return hits
+}
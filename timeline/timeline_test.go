@@ -0,0 +1,179 @@
+package timeline
+
+import (
+	"Bingo/bingo"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testMatch = `{
+	"game": "valorant",
+	"started": "2024-05-01T20:00:00Z",
+	"rounds": [
+		{"number": 1, "start": 0, "attributes": {"alive": 4}, "events": [
+			{"time": 30, "type": "kill", "actor": "a", "attributes": {"weapon": "Sheriff"}},
+			{"time": 31, "type": "kill", "actor": "a", "attributes": {"weapon": "Vandal"}},
+			{"time": 32, "type": "kill", "actor": "a", "attributes": {"weapon": "Vandal"}}
+		]},
+		{"number": 2, "start": 100, "attributes": {"alive": 8}, "events": [
+			{"time": 101, "type": "kill", "actor": "b", "attributes": {"weapon": "Sheriff"}},
+			{"time": 102, "type": "kill", "actor": "a", "attributes": {"weapon": "Vandal"}},
+			{"time": 103, "type": "kill", "actor": "a", "attributes": {"weapon": "Vandal"}},
+			{"time": 104, "type": "kill", "actor": "a", "attributes": {"weapon": "Vandal"}}
+		]}
+	]
+}`
+
+func parseTestMatch(t *testing.T) *Match {
+	match, err := ParseMatch([]byte(testMatch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return match
+}
+
+func TestEvaluate(t *testing.T) {
+	rules := []Rule{
+		{Field: "Sheriff in Pistol", Event: "kill", Where: map[string]interface{}{"weapon": "sheriff", "round.number": []interface{}{1.0, 13.0}}},
+		{Field: "Triple", Event: "kill", Count: 3, PerRound: true, PerActor: true},
+		{Field: "Five", Event: "kill", Count: 5, PerRound: true, PerActor: true},
+		{Field: "Survivors", Event: "roundEnd", Where: map[string]interface{}{"alive": map[string]interface{}{"min": 7.0}}},
+		{Field: "Late Sheriff", Event: "kill", Where: map[string]interface{}{"weapon": "Sheriff", "roundTime": map[string]interface{}{"max": 2.0}}},
+	}
+	hits := Evaluate(parseTestMatch(t), rules)
+
+	expected := []struct {
+		field  string
+		round  int
+		offset float64
+	}{
+		{"Sheriff in Pistol", 1, 30},
+		{"Triple", 1, 32},
+		{"Late Sheriff", 2, 101},
+	}
+	if len(hits) != len(expected) {
+		t.Fatalf("expected %d hits, got %+v", len(expected), hits)
+	}
+	for i, hit := range hits {
+		if hit.Rule.Field != expected[i].field || hit.Round != expected[i].round || hit.Offset != expected[i].offset {
+			t.Errorf("expected hit %d to be %+v, got %+v", i, expected[i], hit)
+		}
+	}
+	if want := time.Date(2024, 5, 1, 20, 0, 30, 0, time.UTC); !hits[0].Time.Equal(want) {
+		t.Errorf("expected the first hit at %s, got %s", want, hits[0].Time)
+	}
+}
+
+func TestParseMatch(t *testing.T) {
+	for _, content := range []string{
+		`{"game": "valorant", "rounds": [{"number": 1}]}`,
+		`{"game": "valorant", "started": "2024-05-01T20:00:00Z", "rounds": []}`,
+		`{`,
+	} {
+		if _, err := ParseMatch([]byte(content)); err == nil {
+			t.Errorf("expected an error for %s", content)
+		}
+	}
+}
+
+func TestValorantRules(t *testing.T) {
+	rules, err := LoadRules("../bingos", "valorant")
+	if err != nil {
+		t.Fatal(err)
+	}
+	match, err := ParseMatch([]byte(`{
+		"game": "valorant",
+		"started": "2024-05-01T20:00:00Z",
+		"rounds": [
+			{"number": 1, "start": 0, "attributes": {"alive": 4}, "events": [
+				{"time": 20, "type": "kill", "actor": "a", "attributes": {"weapon": "Sheriff"}},
+				{"time": 90, "type": "roundEnd"}
+			]},
+			{"number": 2, "start": 100, "attributes": {"alive": 7}, "events": [
+				{"time": 110, "type": "kill", "actor": "b", "attributes": {"weapon": "Judge"}},
+				{"time": 111, "type": "kill", "actor": "b", "attributes": {"weapon": "Judge"}},
+				{"time": 180, "type": "roundEnd", "attributes": {"thrifty": true}}
+			]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"Sheriff in Pistol", "Judge Double Kill", "Round over with 7 Alive", "Thrifty"}
+	hits := Evaluate(match, rules)
+	if len(hits) != len(expected) {
+		t.Fatalf("expected %d hits, got %+v", len(expected), hits)
+	}
+	for i, hit := range hits {
+		if hit.Rule.Field != expected[i] {
+			t.Errorf("expected hit %d to be %s, got %s", i, expected[i], hit.Rule.Field)
+		}
+	}
+	if hits[2].Round != 2 || hits[2].Offset != 180 {
+		t.Errorf("expected 7 alive at the end of round 2, got round %d at %v", hits[2].Round, hits[2].Offset)
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	rules, err := LoadRules("../bingos", "valorant")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) == 0 {
+		t.Fatal("expected rules for valorant")
+	}
+
+	rules, err = LoadRules(t.TempDir(), "valorant")
+	if err != nil || len(rules) != 0 {
+		t.Errorf("expected no rules without a rules file, got %v: %v", rules, err)
+	}
+
+	dir := t.TempDir()
+	for _, content := range []string{`[{"event": "kill"}]`, `[{"field": "Ace", "where": {"kills": {"least": 5}}}]`, `{`} {
+		err = os.WriteFile(filepath.Join(dir, "broken.rules.json"), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = LoadRules(dir, "broken"); err == nil {
+			t.Errorf("expected an error for %s", content)
+		}
+	}
+}
+
+func TestPlanAndReport(t *testing.T) {
+	bin := &bingo.Bingo{Id: "abc", Kind: "valorant", Completed: map[string]bool{"Ace": false, "Teamkill": true}}
+	rules := []Rule{
+		{Name: "first", Field: "ace", Event: "kill"},
+		{Name: "second", Field: "Ace", Event: "kill"},
+		{Name: "teamkill", Field: "Teamkill", Event: "kill"},
+		{Name: "unknown", Field: "Shorty kill", Event: "kill"},
+		{Name: "never", Field: "Ace", Event: "defuse"},
+	}
+	steps := Plan(bin, Evaluate(parseTestMatch(t), rules))
+
+	statuses := []string{StatusMark, StatusEarlierRule, StatusMarked, StatusNotInBingo}
+	if len(steps) != len(statuses) {
+		t.Fatalf("expected %d steps, got %+v", len(statuses), steps)
+	}
+	for i, step := range steps {
+		if step.Status != statuses[i] {
+			t.Errorf("expected step %d to be %q, got %q", i, statuses[i], step.Status)
+		}
+	}
+	if steps[0].Field != "Ace" {
+		t.Errorf("expected the field of the bingo, got %q", steps[0].Field)
+	}
+
+	report := &bytes.Buffer{}
+	WriteReport(report, bin, rules, steps)
+	for _, line := range []string{"0:30", "never", "no hit", "1 fields to mark"} {
+		if !strings.Contains(report.String(), line) {
+			t.Errorf("expected %q in the report:\n%s", line, report)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package twitch
+
+import (
+	"strings"
+)
+
+// message is a line of the IRC protocol with the tags of the twitch extension.
+type message struct {
+	Tags    map[string]string
+	Nick    string
+	Command string
+	Params  []string
+}
+
+// parseMessage parses a line like
+// "@badges=moderator/1;display-name=Viewer :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :!join".
+func parseMessage(line string) message {
+	msg := message{Tags: make(map[string]string)}
+	line = strings.TrimRight(line, "\r\n")
+
+	if strings.HasPrefix(line, "@") {
+		tags, rest, _ := strings.Cut(line[1:], " ")
+		for _, tag := range strings.Split(tags, ";") {
+			key, value, _ := strings.Cut(tag, "=")
+			msg.Tags[key] = unescapeTag(value)
+		}
+		line = rest
+	}
+	if strings.HasPrefix(line, ":") {
+		prefix, rest, _ := strings.Cut(line[1:], " ")
+		msg.Nick, _, _ = strings.Cut(prefix, "!")
+		line = rest
+	}
+
+	line, trailing, hasTrailing := strings.Cut(line, " :")
+	fields := strings.Fields(line)
+	if len(fields) > 0 {
+		msg.Command = strings.ToUpper(fields[0])
+		msg.Params = fields[1:]
+	}
+	if hasTrailing {
+		msg.Params = append(msg.Params, trailing)
+	}
+	return msg
+}
+
+// param returns the parameter at the index or an empty string.
+func (m message) param(index int) string {
+	if index < len(m.Params) {
+		return m.Params[index]
+	}
+	return ""
+}
+
+var tagEscapes = strings.NewReplacer(`\:`, ";", `\s`, " ", `\\`, `\`, `\r`, "\r", `\n`, "\n")
+
+func unescapeTag(value string) string {
+	return tagEscapes.Replace(value)
+}
+
+// clean removes line breaks so a text cannot inject further IRC commands.
+func clean(text string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(text)
+}
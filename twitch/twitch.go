@@ -0,0 +1,334 @@
+// Package twitch lets the viewers of a stream play along in the twitch chat.
+// It talks IRC over TLS to the twitch chat.
+package twitch
+
+import (
+	"Bingo/bingo"
+	"Bingo/bot"
+	"Bingo/config"
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// BoardPrefix is the prefix of the board ids of twitch viewers, the twitch login follows.
+	BoardPrefix = "twitch-"
+
+	dialTimeout    = 10 * time.Second
+	writeTimeout   = 10 * time.Second
+	reconnectDelay = 5 * time.Second
+	// readTimeout is above the five minutes twitch sends a PING after.
+	readTimeout = 6 * time.Minute
+	// sendQueueSize is the number of chat lines waiting to be sent, further lines are dropped.
+	sendQueueSize = 64
+)
+
+// dial connects to the twitch chat, tests replace it with plain TCP.
+var dial = func(address string) (net.Conn, error) {
+	return tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", address, nil)
+}
+
+// Client is the connection to the twitch chat. It reconnects until it is stopped.
+type Client struct {
+	cfg   *config.Config
+	token string
+
+	send chan string
+	stop chan struct{}
+
+	lock    sync.Mutex
+	conn    net.Conn
+	stopped bool
+}
+
+// Start connects to the twitch chat and joins the configured channels in the background.
+func Start(cfg *config.Config, token string) *Client {
+	c := &Client{
+		cfg:   cfg,
+		token: token,
+		send:  make(chan string, sendQueueSize),
+		stop:  make(chan struct{}),
+	}
+	bingo.Listen(c.eventRecorded)
+	go c.run()
+	return c
+}
+
+// Stop disconnects from the twitch chat.
+func (c *Client) Stop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.stopped {
+		return
+	}
+	c.stopped = true
+	close(c.stop)
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
+
+func (c *Client) run() {
+	for {
+		err := c.serve()
+		select {
+		case <-c.stop:
+			return
+		default:
+		}
+		log.WithError(err).Warn("Disconnected from the twitch chat, reconnecting")
+
+		select {
+		case <-c.stop:
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// serve handles a single connection until it fails.
+func (c *Client) serve() error {
+	conn, err := dial(c.cfg.Twitch.Server)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	c.lock.Lock()
+	if c.stopped {
+		c.lock.Unlock()
+		return errors.New("stopped")
+	}
+	c.conn = conn
+	c.lock.Unlock()
+
+	token := c.token
+	if !strings.HasPrefix(token, "oauth:") {
+		token = "oauth:" + token
+	}
+	login := []string{
+		"PASS " + token,
+		"NICK " + strings.ToLower(c.cfg.Twitch.Nick),
+		"CAP REQ :twitch.tv/tags twitch.tv/commands",
+	}
+	for channel := range c.cfg.Twitch.Channels {
+		login = append(login, "JOIN #"+channel)
+	}
+	for _, line := range login {
+		err = writeLine(conn, line)
+		if err != nil {
+			return err
+		}
+	}
+	log.WithField("channels", len(c.cfg.Twitch.Channels)).Info("Connected to the twitch chat")
+
+	done := make(chan struct{})
+	defer close(done)
+	go c.writeLoop(conn, done)
+
+	reader := bufio.NewReader(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		msg := parseMessage(line)
+		switch msg.Command {
+		case "PING":
+			c.queue("PONG :" + msg.param(0))
+		case "RECONNECT":
+			return errors.New("the twitch chat asked to reconnect")
+		case "NOTICE":
+			if strings.Contains(msg.param(1), "authentication failed") {
+				return errors.New("twitch login failed: " + msg.param(1))
+			}
+		case "PRIVMSG":
+			c.handleChat(msg)
+		}
+	}
+}
+
+func (c *Client) writeLoop(conn net.Conn, done chan struct{}) {
+	for {
+		select {
+		case line := <-c.send:
+			err := writeLine(conn, line)
+			if err != nil {
+				log.WithError(err).Warn("Could not write to the twitch chat")
+				conn.Close()
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+func writeLine(conn net.Conn, line string) error {
+	conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err := conn.Write([]byte(line + "\r\n"))
+	return err
+}
+
+// queue sends the line without waiting for the connection.
+func (c *Client) queue(line string) {
+	c.lock.Lock()
+	stopped := c.stopped
+	c.lock.Unlock()
+	if stopped {
+		return
+	}
+
+	select {
+	case c.send <- line:
+	default:
+		log.Warn("Twitch chat queue is full, dropping message")
+	}
+}
+
+// say sends a chat message to the channel.
+func (c *Client) say(channel, text string) {
+	c.queue("PRIVMSG #" + channel + " :" + clean(text))
+}
+
+// handleChat runs the chat commands of the viewers.
+func (c *Client) handleChat(msg message) {
+	channel := strings.TrimPrefix(msg.param(0), "#")
+	guild, exists := c.cfg.Twitch.Channels[channel]
+	if !exists {
+		return
+	}
+	fields := strings.Fields(msg.param(1))
+	if len(fields) == 0 || (fields[0] != "!join" && fields[0] != "!mark") {
+		return
+	}
+
+	bin := bingo.Latest(guild)
+	if bin == nil {
+		c.say(channel, "No bingo is running right now.")
+		return
+	}
+
+	name := msg.Tags["display-name"]
+	if name == "" {
+		name = msg.Nick
+	}
+	switch fields[0] {
+	case "!join":
+		c.join(channel, bin, msg.Nick, name)
+	case "!mark":
+		if !isModerator(msg, channel) {
+			c.say(channel, "@"+name+" only the host and the moderators can mark fields.")
+			return
+		}
+		c.mark(channel, bin, strings.Join(fields[1:], " "))
+	}
+}
+
+func (c *Client) join(channel string, bin *bingo.Bingo, nick, name string) {
+	bin.Lock()
+	_, exists := bin.Boards[BoardPrefix+nick]
+	full := !exists && countViewerBoards(bin) >= c.cfg.Twitch.MaxBoards
+	if !exists && !full {
+		bin.CreateBoard(BoardPrefix+nick, name)
+		bin.Store(c.cfg.StoragePath)
+		bot.Broadcast(bin.Id, []byte("Host"))
+		bot.RefreshBoards(bin)
+	}
+	bin.Unlock()
+
+	switch {
+	case exists:
+		c.say(channel, "@"+name+" you already play, find your board on the overlay.")
+	case full:
+		c.say(channel, "@"+name+" the bingo is full, no more viewers can join.")
+	default:
+		c.say(channel, "@"+name+" joined the bingo, find your board on the overlay.")
+	}
+}
+
+// countViewerBoards returns the number of boards created from the chat. The caller holds the lock of the bingo.
+func countViewerBoards(bin *bingo.Bingo) int {
+	count := 0
+	for id := range bin.Boards {
+		if strings.HasPrefix(id, BoardPrefix) {
+			count++
+		}
+	}
+	return count
+}
+
+func (c *Client) mark(channel string, bin *bingo.Bingo, field string) {
+	bin.Lock()
+	word, err := findField(bin, field)
+	bin.Unlock()
+	if err != nil {
+		c.say(channel, err.Error())
+		return
+	}
+	if !bot.SetField(bin, word, true) {
+		c.say(channel, word+" is already marked.")
+		return
+	}
+	c.say(channel, "Marked "+word+".")
+}
+
+// findField returns the field of the bingo that is named like the text or the
+// only field containing it, ignoring the case.
+func findField(bin *bingo.Bingo, text string) (string, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "" {
+		return "", errors.New("Usage: !mark <field>")
+	}
+
+	matches := make([]string, 0)
+	for word := range bin.Completed {
+		lower := strings.ToLower(word)
+		if lower == text {
+			return word, nil
+		}
+		if strings.Contains(lower, text) {
+			matches = append(matches, word)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", errors.New("No field matches " + text + ".")
+	case 1:
+		return matches[0], nil
+	}
+	return "", errors.New(text + " matches several fields, please be more specific.")
+}
+
+// isModerator reports whether the sender of the message is the broadcaster or a moderator of the channel.
+func isModerator(msg message, channel string) bool {
+	if msg.Nick == channel || msg.Tags["mod"] == "1" {
+		return true
+	}
+	for _, badge := range strings.Split(msg.Tags["badges"], ",") {
+		if strings.HasPrefix(badge, "broadcaster/") || strings.HasPrefix(badge, "moderator/") {
+			return true
+		}
+	}
+	return false
+}
+
+// eventRecorded announces a bingo in the chat of the channels playing the bingo.
+func (c *Client) eventRecorded(bin *bingo.Bingo, event bingo.Event) {
+	if event.Type != bingo.EventBingo {
+		return
+	}
+	for channel, guild := range c.cfg.Twitch.Channels {
+		if guild == bin.GuildId {
+			c.say(channel, "🎉 "+event.String())
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package twitch
+
+import (
+	"Bingo/bingo"
+	"Bingo/bot"
+	"Bingo/config"
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer is an IRC server accepting a single client.
+type fakeServer struct {
+	t        *testing.T
+	listener net.Listener
+	conn     net.Conn
+	lines    chan string
+}
+
+// newFakeServer listens for the client on plain TCP instead of TLS.
+func newFakeServer(t *testing.T) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	tlsDial := dial
+	dial = func(address string) (net.Conn, error) { return net.DialTimeout("tcp", address, dialTimeout) }
+	t.Cleanup(func() { dial = tlsDial })
+	return &fakeServer{t: t, listener: listener, lines: make(chan string, 100)}
+}
+
+func (s *fakeServer) accept() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	s.conn = conn
+	s.t.Cleanup(func() { conn.Close() })
+	go func() {
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			s.lines <- scanner.Text()
+		}
+	}()
+}
+
+func (s *fakeServer) write(line string) {
+	_, err := s.conn.Write([]byte(line + "\r\n"))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+// expect waits for a line from the client starting with prefix and skips all others.
+func (s *fakeServer) expect(prefix string) string {
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case line := <-s.lines:
+			if strings.HasPrefix(line, prefix) {
+				return line
+			}
+		case <-timeout:
+			s.t.Fatalf("timeout waiting for %q", prefix)
+			return ""
+		}
+	}
+}
+
+func chat(nick, tags, text string) string {
+	return "@" + tags + " :" + nick + "!" + nick + "@" + nick + ".tmi.twitch.tv PRIVMSG #streamer :" + text
+}
+
+func TestChat(t *testing.T) {
+	server := newFakeServer(t)
+	cfg := config.Default()
+	cfg.WordsPath = "../bingos/"
+	cfg.StoragePath = t.TempDir() + "/"
+	cfg.Twitch.Server = server.listener.Addr().String()
+	cfg.Twitch.Nick = "BingoBot"
+	cfg.Twitch.Channels = map[string]string{"streamer": "100"}
+	bot.SetConfig(cfg)
+	bingo.Bingos = make(map[string]*bingo.Bingo)
+
+	client := Start(cfg, "secret")
+	defer client.Stop()
+	server.accept()
+	server.expect("PASS oauth:secret")
+	server.expect("NICK bingobot")
+	server.expect("JOIN #streamer")
+
+	server.write("PING :tmi.twitch.tv")
+	server.expect("PONG :tmi.twitch.tv")
+
+	server.write(chat("viewer", "display-name=Viewer", "!join"))
+	server.expect("PRIVMSG #streamer :No bingo is running")
+
+	bin, err := bingo.Create(cfg, "100", "300", "valorant", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bingo.AddBingo(bin)
+
+	server.write(chat("viewer", "display-name=Viewer", "!join"))
+	server.expect("PRIVMSG #streamer :@Viewer joined the bingo")
+	bin.Lock()
+	board := bin.Boards[BoardPrefix+"viewer"]
+	bin.Unlock()
+	if board == nil || board.UserName != "Viewer" {
+		t.Fatal("expected a board for the viewer")
+	}
+	server.write(chat("viewer", "display-name=Viewer", "!join"))
+	server.expect("PRIVMSG #streamer :@Viewer you already play")
+
+	server.write(chat("viewer", "display-name=Viewer", "!mark "+board.Content[0]))
+	server.expect("PRIVMSG #streamer :@Viewer only the host and the moderators")
+	bin.Lock()
+	marked := bin.Completed[board.Content[0]]
+	bin.Unlock()
+	if marked {
+		t.Fatal("expected viewers not to mark fields")
+	}
+
+	width := bingo.Guild("100").BoardWidth
+	for _, word := range board.Content[:width-1] {
+		server.write(chat("mod", "badges=moderator/1;mod=1", "!mark "+strings.ToUpper(word)))
+		server.expect("PRIVMSG #streamer :Marked " + word)
+	}
+	// The bingo is announced while the field is marked
+	server.write(chat("mod", "badges=moderator/1;mod=1", "!mark "+board.Content[width-1]))
+	server.expect("PRIVMSG #streamer :🎉 BINGO for Viewer!")
+	server.expect("PRIVMSG #streamer :Marked " + board.Content[width-1])
+
+	server.write(chat("streamer", "", "!mark "+board.Content[0]))
+	server.expect("PRIVMSG #streamer :" + board.Content[0] + " is already marked")
+}
+
+func TestConcurrentJoins(t *testing.T) {
+	server := newFakeServer(t)
+	cfg := config.Default()
+	cfg.WordsPath = "../bingos/"
+	cfg.StoragePath = t.TempDir() + "/"
+	cfg.Twitch.Server = server.listener.Addr().String()
+	cfg.Twitch.Nick = "bingobot"
+	cfg.Twitch.Channels = map[string]string{"streamer": "101"}
+	bot.SetConfig(cfg)
+	bingo.Bingos = make(map[string]*bingo.Bingo)
+	bin, err := bingo.Create(cfg, "101", "300", "valorant", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bingo.AddBingo(bin)
+
+	client := Start(cfg, "oauth:secret")
+	defer client.Stop()
+	server.accept()
+	server.expect("PASS oauth:secret")
+
+	// Boards created from the chat and from discord at the same time must not race
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			bin.Lock()
+			bin.CreateBoard("discord-"+strconv.Itoa(i), "player")
+			bin.Unlock()
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		server.write(chat("viewer"+strconv.Itoa(i), "", "!join"))
+	}
+	for i := 0; i < 20; i++ {
+		server.expect("PRIVMSG #streamer :@viewer")
+	}
+	<-done
+
+	bin.Lock()
+	defer bin.Unlock()
+	if len(bin.Boards) != 40 {
+		t.Errorf("expected 40 boards, got %d", len(bin.Boards))
+	}
+}
+
+func TestJoinLimit(t *testing.T) {
+	server := newFakeServer(t)
+	cfg := config.Default()
+	cfg.WordsPath = "../bingos/"
+	cfg.StoragePath = t.TempDir() + "/"
+	cfg.Twitch.Server = server.listener.Addr().String()
+	cfg.Twitch.Nick = "bingobot"
+	cfg.Twitch.Channels = map[string]string{"streamer": "102"}
+	cfg.Twitch.MaxBoards = 1
+	bot.SetConfig(cfg)
+	bingo.Bingos = make(map[string]*bingo.Bingo)
+	bin, err := bingo.Create(cfg, "102", "300", "valorant", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bingo.AddBingo(bin)
+
+	broadcasts := make(chan string, 10)
+	bot.Broadcast = func(bingoId string, message []byte) { broadcasts <- bingoId + ";" + string(message) }
+	t.Cleanup(func() { bot.Broadcast = func(bingoId string, message []byte) {} })
+
+	client := Start(cfg, "oauth:secret")
+	defer client.Stop()
+	server.accept()
+	server.expect("PASS oauth:secret")
+
+	server.write(chat("first", "", "!join"))
+	server.expect("PRIVMSG #streamer :@first joined the bingo")
+	if message := <-broadcasts; message != bin.Id+";Host" {
+		t.Errorf("expected the overlay to reload, got %q", message)
+	}
+	server.write(chat("second", "", "!join"))
+	server.expect("PRIVMSG #streamer :@second the bingo is full")
+
+	bin.Lock()
+	defer bin.Unlock()
+	if len(bin.Boards) != 1 {
+		t.Errorf("expected 1 board, got %d", len(bin.Boards))
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	msg := parseMessage("@badges=broadcaster/1;display-name=The\\sStreamer :streamer!streamer@streamer.tmi.twitch.tv PRIVMSG #streamer :!mark Ace round\r\n")
+	if msg.Command != "PRIVMSG" || msg.Nick != "streamer" || msg.param(0) != "#streamer" || msg.param(1) != "!mark Ace round" {
+		t.Errorf("unexpected message %+v", msg)
+	}
+	if msg.Tags["display-name"] != "The Streamer" || !isModerator(msg, "streamer") {
+		t.Errorf("unexpected tags %v", msg.Tags)
+	}
+}
@@ -0,0 +1,295 @@
+// Package webhook delivers the events of bingos to the webhooks of the bingo and its guild.
+package webhook
+
+import (
+	"Bingo/bingo"
+	"Bingo/config"
+	"Bingo/random"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// EventTest is the event type of test deliveries.
+	EventTest = "test"
+
+	// SignatureHeader holds "sha256=" and the hex encoded HMAC-SHA256 of the body with the secret of the webhook.
+	SignatureHeader = "X-Bingo-Signature"
+	EventHeader     = "X-Bingo-Event"
+	DeliveryHeader  = "X-Bingo-Delivery"
+
+	maxAttempts = 5
+	// queueSize is the number of events a webhook can have waiting. Further events are dropped until the queue drains.
+	queueSize = 64
+	// logSize is the number of deliveries kept per webhook.
+	logSize = 50
+)
+
+// initialBackoff is the wait before the first retry, it doubles with every further retry.
+var initialBackoff = 2 * time.Second
+
+// client refuses to connect to private networks so webhooks cannot reach the services next to the bot.
+var client = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{Timeout: 10 * time.Second, Control: checkAddress}).DialContext,
+	},
+}
+
+// allowPrivateNetworks is webhooks.allowPrivateNetworks of the current config.
+var allowPrivateNetworks atomic.Bool
+
+// ErrPrivateAddress is returned for deliveries to loopback, link-local and private addresses.
+var ErrPrivateAddress = errors.New("webhooks may not be delivered to private addresses")
+
+// Payload is the body of every webhook request.
+type Payload struct {
+	BingoId string      `json:"bingoID"`
+	GuildId string      `json:"guildID"`
+	Kind    string      `json:"kind"`
+	Event   bingo.Event `json:"event"`
+}
+
+// Delivery is the result of sending an event to a webhook.
+type Delivery struct {
+	Id        string    `json:"id"`
+	WebhookId string    `json:"webhookID"`
+	Event     string    `json:"event"`
+	Time      time.Time `json:"time"`
+	Attempts  int       `json:"attempts"`
+	// Status is the HTTP status of the last attempt, 0 if there was no response.
+	Status    int    `json:"status"`
+	Error     string `json:"error,omitempty"`
+	Delivered bool   `json:"delivered"`
+}
+
+type job struct {
+	webhook bingo.Webhook
+	payload Payload
+}
+
+var (
+	queues     = make(map[string]chan job)
+	deliveries = make(map[string][]Delivery)
+	lock       sync.Mutex
+	started    sync.Once
+)
+
+// Start delivers the events of all bingos from now on.
+func Start() {
+	started.Do(func() { bingo.Listen(eventRecorded) })
+}
+
+// SetConfig replaces the config of the deliveries.
+func SetConfig(cfg *config.Config) {
+	allowPrivateNetworks.Store(cfg.Webhooks.AllowPrivateNetworks)
+}
+
+// Remove stops the deliveries to the webhook, events still waiting for it are dropped.
+func Remove(webhookId string) {
+	lock.Lock()
+	defer lock.Unlock()
+	queue, exists := queues[webhookId]
+	if !exists {
+		return
+	}
+	delete(queues, webhookId)
+	close(queue)
+}
+
+// eventRecorded queues the event for every webhook of the bingo and its guild that wants it.
+func eventRecorded(bin *bingo.Bingo, event bingo.Event) {
+	webhooks := append(append([]bingo.Webhook{}, bin.Webhooks...), bingo.Guild(bin.GuildId).Webhooks...)
+	for _, webhook := range webhooks {
+		if !webhook.Wants(event.Type) {
+			continue
+		}
+		enqueue(job{webhook: webhook, payload: Payload{
+			BingoId: bin.Id,
+			GuildId: bin.GuildId,
+			Kind:    bin.Kind,
+			Event:   event,
+		}})
+	}
+}
+
+// enqueue hands the job to the worker of its webhook. Every webhook has its own
+// worker so a failing webhook only delays its own events, which stay in order.
+func enqueue(j job) {
+	lock.Lock()
+	defer lock.Unlock()
+	queue, exists := queues[j.webhook.Id]
+	if !exists {
+		queue = make(chan job, queueSize)
+		queues[j.webhook.Id] = queue
+		go worker(j.webhook.Id, queue)
+	}
+
+	select {
+	case queue <- j:
+	default:
+		log.WithField("webhook", j.webhook.Id).Warn("Webhook queue is full, dropping event")
+	}
+}
+
+// worker delivers the jobs of the webhook until the webhook is removed.
+func worker(webhookId string, queue chan job) {
+	for j := range queue {
+		lock.Lock()
+		removed := queues[webhookId] != queue
+		lock.Unlock()
+		if removed {
+			continue
+		}
+		deliver(j.webhook, j.payload, maxAttempts)
+	}
+}
+
+// Test sends a test event to the webhook once and returns the result.
+func Test(webhook bingo.Webhook, bin *bingo.Bingo) Delivery {
+	return deliver(webhook, Payload{
+		BingoId: bin.Id,
+		GuildId: bin.GuildId,
+		Kind:    bin.Kind,
+		Event:   bingo.Event{Type: EventTest, Time: time.Now()},
+	}, 1)
+}
+
+// deliver posts the payload until the webhook accepts it or the attempts are used up.
+func deliver(webhook bingo.Webhook, payload Payload, attempts int) Delivery {
+	delivery := Delivery{
+		Id:        random.RandSeq(12),
+		WebhookId: webhook.Id,
+		Event:     payload.Event.Type,
+		Time:      time.Now(),
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		delivery.Error = err.Error()
+		record(delivery)
+		return delivery
+	}
+
+	backoff := initialBackoff
+	for delivery.Attempts < attempts {
+		if delivery.Attempts > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		delivery.Attempts++
+
+		var retry bool
+		delivery.Status, retry, err = post(webhook, delivery.Id, payload.Event.Type, body)
+		if err == nil {
+			delivery.Delivered = true
+			delivery.Error = ""
+			break
+		}
+		delivery.Error = err.Error()
+		if !retry {
+			break
+		}
+	}
+
+	if !delivery.Delivered {
+		log.WithField("webhook", webhook.Id).WithField("attempts", delivery.Attempts).
+			WithField("error", delivery.Error).Warn("Webhook delivery failed")
+	}
+	record(delivery)
+	return delivery
+}
+
+// post sends the body once. It reports whether a failed request should be retried.
+func post(webhook bingo.Webhook, deliveryId, eventType string, body []byte) (int, bool, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventType)
+	req.Header.Set(DeliveryHeader, deliveryId)
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, !errors.Is(err, ErrPrivateAddress), err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+	// Client errors besides rate limits will not go away by retrying
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return resp.StatusCode, retry, &statusError{resp.StatusCode}
+}
+
+// checkAddress refuses connections to loopback, link-local and private
+// addresses unless webhooks.allowPrivateNetworks is set. It runs after the host
+// name is resolved, so a public name pointing to a private address is refused as well.
+func checkAddress(network, address string, _ syscall.RawConn) error {
+	if allowPrivateNetworks.Load() {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+type statusError struct {
+	status int
+}
+
+func (e *statusError) Error() string {
+	return "the webhook answered with status " + strconv.Itoa(e.status)
+}
+
+// Sign returns the signature header value of the body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func record(delivery Delivery) {
+	lock.Lock()
+	defer lock.Unlock()
+	entries := append(deliveries[delivery.WebhookId], delivery)
+	if len(entries) > logSize {
+		entries = entries[len(entries)-logSize:]
+	}
+	deliveries[delivery.WebhookId] = entries
+}
+
+// Deliveries returns the last deliveries to the webhook, newest first.
+func Deliveries(webhookId string) []Delivery {
+	lock.Lock()
+	defer lock.Unlock()
+	entries := deliveries[webhookId]
+	newestFirst := make([]Delivery, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		newestFirst = append(newestFirst, entries[i])
+	}
+	return newestFirst
+}
@@ -0,0 +1,203 @@
+package webhook
+
+import (
+	"Bingo/bingo"
+	"Bingo/random"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver is a webhook endpoint answering with the given statuses in order and 200 afterwards.
+type receiver struct {
+	lock     sync.Mutex
+	statuses []int
+	payloads []Payload
+	valid    []bool
+}
+
+func (r *receiver) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	payload := Payload{}
+	json.Unmarshal(body, &payload)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.payloads = append(r.payloads, payload)
+	r.valid = append(r.valid, req.Header.Get(SignatureHeader) == Sign("secret", body) &&
+		req.Header.Get(EventHeader) == payload.Event.Type)
+	if len(r.statuses) > 0 {
+		resp.WriteHeader(r.statuses[0])
+		r.statuses = r.statuses[1:]
+	}
+}
+
+func (r *receiver) received() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.payloads)
+}
+
+func init() {
+	initialBackoff = time.Millisecond
+	// The receivers of the tests listen on the loopback address
+	allowPrivateNetworks.Store(true)
+}
+
+func TestDeliverRetries(t *testing.T) {
+	r := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusTooManyRequests}}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	hook := bingo.Webhook{Id: random.RandSeq(8), Url: server.URL, Secret: "secret"}
+	delivery := deliver(hook, Payload{BingoId: "b", Event: bingo.Event{Type: bingo.EventBingo}}, maxAttempts)
+	if !delivery.Delivered || delivery.Attempts != 3 || delivery.Status != http.StatusOK {
+		t.Errorf("expected delivery on the third attempt, got %+v", delivery)
+	}
+	for i, valid := range r.valid {
+		if !valid {
+			t.Errorf("attempt %d has an invalid signature", i+1)
+		}
+	}
+	if log := Deliveries(hook.Id); len(log) != 1 || log[0].Id != delivery.Id {
+		t.Errorf("expected the delivery in the log, got %v", log)
+	}
+}
+
+func TestDeliverGivesUp(t *testing.T) {
+	r := &receiver{statuses: []int{http.StatusBadRequest}}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	hook := bingo.Webhook{Id: random.RandSeq(8), Url: server.URL, Secret: "secret"}
+	delivery := deliver(hook, Payload{Event: bingo.Event{Type: bingo.EventBingo}}, maxAttempts)
+	if delivery.Delivered || delivery.Attempts != 1 || delivery.Status != http.StatusBadRequest {
+		t.Errorf("expected no retry after a client error, got %+v", delivery)
+	}
+
+	r.statuses = []int{500, 500, 500, 500, 500}
+	delivery = Test(hook, &bingo.Bingo{Id: "b"})
+	if delivery.Delivered || delivery.Attempts != 1 || delivery.Event != EventTest {
+		t.Errorf("expected a single failed test attempt, got %+v", delivery)
+	}
+	if log := Deliveries(hook.Id); len(log) != 2 || log[0].Event != EventTest {
+		t.Errorf("expected both deliveries newest first, got %v", log)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+	Start()
+
+	bin := &bingo.Bingo{
+		Id:        "events",
+		GuildId:   "100",
+		Kind:      "valorant",
+		Completed: map[string]bool{"a": false},
+		Webhooks: []bingo.Webhook{
+			{Id: random.RandSeq(8), Url: server.URL, Secret: "secret"},
+			{Id: random.RandSeq(8), Url: server.URL, Secret: "secret", Events: []string{bingo.EventBingo}},
+		},
+	}
+	bin.Record(bingo.Event{Type: bingo.EventFieldCompleted, Field: "a"})
+	bin.Record(bingo.Event{Type: bingo.EventBingo, UserName: "player"})
+	bin.Record(bingo.Event{Type: bingo.EventWordReplaced})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for r.received() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(r.payloads) != 3 {
+		t.Fatalf("expected 3 deliveries, got %d", len(r.payloads))
+	}
+	bingos := 0
+	for _, payload := range r.payloads {
+		if payload.BingoId != "events" || payload.GuildId != "100" || payload.Kind != "valorant" {
+			t.Errorf("unexpected payload %+v", payload)
+		}
+		if payload.Event.Type == bingo.EventWordReplaced {
+			t.Errorf("expected events not sent to webhooks to be skipped")
+		}
+		if payload.Event.Type == bingo.EventBingo {
+			bingos++
+		}
+	}
+	if bingos != 2 {
+		t.Errorf("expected the bingo at both webhooks, got %d", bingos)
+	}
+}
+
+func TestNewWebhook(t *testing.T) {
+	hook, err := bingo.NewWebhook("https://example.com/hook", []string{bingo.EventBingo})
+	if err != nil || hook.Id == "" || len(hook.Secret) != 64 {
+		t.Errorf("unexpected webhook %+v: %v", hook, err)
+	}
+	if _, err := bingo.NewWebhook("example.com", nil); err == nil {
+		t.Errorf("expected relative urls to be rejected")
+	}
+	if _, err := bingo.NewWebhook("https://example.com", []string{"unknown"}); err == nil {
+		t.Errorf("expected unknown events to be rejected")
+	}
+}
+
+func TestPrivateAddresses(t *testing.T) {
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+	allowPrivateNetworks.Store(false)
+	defer allowPrivateNetworks.Store(true)
+
+	for _, address := range []string{server.URL, "http://10.0.0.1/hook", "http://169.254.169.254/latest", "http://[::1]:80/"} {
+		hook := bingo.Webhook{Id: random.RandSeq(8), Url: address, Secret: "secret"}
+		delivery := Test(hook, &bingo.Bingo{Id: "b"})
+		if delivery.Delivered || delivery.Attempts != 1 {
+			t.Errorf("expected the delivery to %s to be refused, got %+v", address, delivery)
+		}
+		delivery = deliver(hook, Payload{Event: bingo.Event{Type: bingo.EventBingo}}, maxAttempts)
+		if delivery.Attempts != 1 {
+			t.Errorf("expected no retry for %s, got %d attempts", address, delivery.Attempts)
+		}
+	}
+	if r.received() != 0 {
+		t.Errorf("expected nothing to reach the loopback receiver")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	release := make(chan struct{})
+	received := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		received <- struct{}{}
+		<-release
+	}))
+	defer server.Close()
+
+	hook := bingo.Webhook{Id: random.RandSeq(8), Url: server.URL, Secret: "secret"}
+	enqueue(job{webhook: hook, payload: Payload{Event: bingo.Event{Type: bingo.EventBingo}}})
+	<-received
+	enqueue(job{webhook: hook, payload: Payload{Event: bingo.Event{Type: bingo.EventBingo}}})
+	Remove(hook.Id)
+	Remove(hook.Id)
+	close(release)
+
+	lock.Lock()
+	_, exists := queues[hook.Id]
+	lock.Unlock()
+	if exists {
+		t.Errorf("expected the queue of the removed webhook to be gone")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if len(received) != 0 {
+		t.Errorf("expected the waiting event of the removed webhook to be dropped")
+	}
+}
@@ -46,6 +46,9 @@ type Client struct {
 
 	// Buffered channel of outbound messages.
 	send chan []byte
+
+	// The bingo whose messages the client receives.
+	bingoId string
 }
 
 // readPump pumps messages from the websocket connection to the hub.
@@ -70,7 +73,7 @@ func (c *Client) readPump() {
 			break
 		}
 		message = bytes.TrimSpace(bytes.Replace(message, newline, space, -1))
-		c.hub.Broadcast <- message
+		c.hub.Send(c.bingoId, message)
 	}
 }
 
@@ -120,14 +123,15 @@ func (c *Client) writePump() {
 	}
 }
 
-// serveWs handles websocket requests from the peer.
+// serveWs handles websocket requests from the peer. The bingo query parameter
+// selects the bingo the client receives messages of.
 func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println(err)
 		return
 	}
-	client := &Client{hub: hub, conn: conn, send: make(chan []byte, 256)}
+	client := &Client{hub: hub, conn: conn, send: make(chan []byte, 256), bingoId: r.URL.Query().Get("bingo")}
 	client.hub.register <- client
 
 	// Allow collection of memory referenced by the caller by doing all work in
@@ -4,6 +4,12 @@
 
 package webhub
 
+// Message is sent to the clients that watch its bingo.
+type Message struct {
+	BingoId string
+	Data    []byte
+}
+
 // Hub maintains the set of active clients and broadcasts messages to the
 // clients.
 type Hub struct {
@@ -11,7 +17,7 @@ type Hub struct {
 	clients map[*Client]bool
 
 	// Inbound messages from the clients.
-	Broadcast chan []byte
+	Broadcast chan Message
 
 	// Register requests from the clients.
 	register chan *Client
@@ -22,7 +28,7 @@ type Hub struct {
 
 func NewHub() *Hub {
 	return &Hub{
-		Broadcast:  make(chan []byte),
+		Broadcast:  make(chan Message),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		clients:    make(map[*Client]bool),
@@ -41,8 +47,11 @@ func (h *Hub) Run() {
 			}
 		case message := <-h.Broadcast:
 			for client := range h.clients {
+				if client.bingoId != message.BingoId {
+					continue
+				}
 				select {
-				case client.send <- message:
+				case client.send <- message.Data:
 				default:
 					close(client.send)
 					delete(h.clients, client)
@@ -51,3 +60,8 @@ func (h *Hub) Run() {
 		}
 	}
 }
+
+// Send broadcasts the data to the clients of the bingo.
+func (h *Hub) Send(bingoId string, data []byte) {
+	h.Broadcast <- Message{BingoId: bingoId, Data: data}
+}
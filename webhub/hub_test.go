@@ -0,0 +1,34 @@
+package webhub
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSendToBingo(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	watching := &Client{hub: hub, send: make(chan []byte, 1), bingoId: "bingo1"}
+	other := &Client{hub: hub, send: make(chan []byte, 1), bingoId: "bingo2"}
+	hub.register <- watching
+	hub.register <- other
+
+	hub.Send("bingo1", []byte("Ace;true"))
+	select {
+	case message := <-watching.send:
+		if string(message) != "Ace;true" {
+			t.Errorf("unexpected message %q", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the message for the bingo")
+	}
+
+	// Once the other client has the message the hub has skipped the watching one
+	hub.Send("bingo2", []byte("Reroll"))
+	if message := <-other.send; string(message) != "Reroll" {
+		t.Errorf("expected only the message of its own bingo, got %q", message)
+	}
+	if len(watching.send) != 0 {
+		t.Errorf("expected no message of another bingo")
+	}
+}